@@ -1,29 +1,181 @@
 package main
 
 const (
-	name         = "movie-ticket"
-	showKeyIndex = "TheatreId~ShowDate~ShowTime~MovieHallNo"
+	name             = "movie-ticket"
+	showKeyIndex     = "TheatreId~ShowDate~ShowTime~MovieHallNo"
+	seatMapKeyIndex  = "TheatreId~MovieHallNo"
+	soldSeatKeyIndex = "TheatreId~ShowDate~ShowTime~MovieHallNo~Seat"
+
+	showSeatCountKeyIndex = "SeatCount~TheatreId~ShowDate~ShowTime~MovieHallNo"
+	movieHallKeyIndex     = "MovieHall~TheatreId~MovieHallNo"
+	priceListKeyIndex     = "PriceList~TheatreId"
+	cafeteriaItemKeyIndex = "CafeteriaItem~TheatreId~Sku"
+	redemptionKeyIndex    = "Redemption~TicketId~Sku"
+	promotionKeyIndex     = "Promotion~TheatreId~PromotionId"
+	luckyDrawKeyIndex     = "LuckyDraw~TheatreId~ShowDate~ShowTime~MovieHallNo"
+	ticketOrderKeyIndex   = "TicketOrder~TicketId~OrderId"
+	clientHoldKeyIndex    = "ClientHold~OwnerMspId~OwnerId~HoldId"
+
+	maxLuckyNo = 100 // Lucky no. of a ticket is from 1 to maxLuckyNo
+
+	seatHoldMinutes    = 10 // Seats held by Hold_seats are released after seatHoldMinutes unless confirmed
+	maxActiveSeatHolds = 3  // Seat holds a client can have which are neither confirmed nor expired
+
+	sodaBottleSku  = "SODA"        // Cafeteria item given by Replace_with_soda_bottle
+	sodaBottleName = "Soda bottle" // Name of the soda bottle item created by Migrate_cafeteria_stock
+
+	// Keys written before the cafeteria catalogue, see LegacyCafeteria and Migrate_cafeteria_stock
+	legacyCafeteriaKeyPrefix   = "cafeteria_" // Followed by theatre id, soda bottle stock of the theatre
+	legacyReplacementKeyPrefix = "replace_"   // Followed by ticket id, soda bottle is given against the ticket
+
+	roleAttribute    = "role" // Client certificate attribute holding the client's role
+	roleTheatreAdmin = "theatre_admin"
+	roleBoxOffice    = "box_office"
+	roleCustomer     = "customer"
+	roleChannelAdmin = "channel_admin" // Issued only by the organisation governing the channel, assigns owners of legacy theatres
+
+	customerDetailsCollection   = "collectionCustomerDetails" // Private data collection for customer's personal information
+	customerDetailsTransientKey = "customer"                  // Transient map key carrying customer's personal information
+	minCustomerSaltLength       = 16                          // Salt shorter than this lets customer details be guessed from the hash on ledger
+
+	// CouchDB indexes shipped in META-INF/statedb/couchdb/indexes
+	showIndex               = "indexShow"
+	showByTheatreIndex      = "indexShowByTheatre"
+	showByTheatreDateIndex  = "indexShowByTheatreDate"
+	showByIdIndex           = "indexShowById"
+	showByNameIndex         = "indexShowByName"
+	ticketByShowIndex       = "indexTicketByShow"
+	orderByShowIndex        = "indexCafeteriaOrderByShow"
+	ticketByOwnerIndex      = "indexTicketByOwner"
+	ticketByOwnerStartIndex = "indexTicketByOwnerStart"
+
+	// Chaincode events, payload of every event is an Event. Version of an event is changed only when its data changes
+	eventSchemaVersion             = "1.0"
+	cafeteriaInventoryAddedVersion = "2.0" // Data has sku and stock of the item instead of soda bottle quantity
+	ticketBookedEvent              = "TicketBooked"
+	ticketCancelledEvent           = "TicketCancelled"
+	cafeteriaItemRedeemedEvent     = "CafeteriaItemRedeemed"
+	sodaBottleReplacedEvent        = "SodaBottleReplaced" // Emitted by Replace_with_soda_bottle instead of CafeteriaItemRedeemed
+	showRegisteredEvent            = "ShowRegistered"
+	cafeteriaInventoryAddedEvent   = "CafeteriaInventoryAdded"
+	showUpdatedEvent               = "ShowUpdated"
+	showRescheduledEvent           = "ShowRescheduled"
+	showCancelledEvent             = "ShowCancelled"
+	cafeteriaOrderPlacedEvent      = "CafeteriaOrderPlaced"
+	cafeteriaOrderUpdatedEvent     = "CafeteriaOrderUpdated"
+	luckyDrawCommittedEvent        = "LuckyDrawCommitted"
+	luckyDrawRevealedEvent         = "LuckyDrawRevealed"
+	ticketTransferRequestedEvent   = "TicketTransferRequested"
+	ticketTransferredEvent         = "TicketTransferred"
+	ticketTransferCancelledEvent   = "TicketTransferCancelled"
+	theatreTransferRequestedEvent  = "TheatreTransferRequested"
+	theatreTransferredEvent        = "TheatreTransferred"
+	theatreTransferCancelledEvent  = "TheatreTransferCancelled"
+
+	showStatusScheduled = "SCHEDULED"
+	showStatusCancelled = "CANCELLED"
+
+	screenType2D   = "2D"
+	screenType3D   = "3D"
+	screenTypeIMAX = "IMAX"
+
+	seatTypeStandard = "standard" // Seat type of every seat in a movie hall without seat map
+	seatTypePremium  = "premium"
+	seatTypeRecliner = "recliner"
+
+	ticketStatusBooked         = "BOOKED"
+	ticketStatusCancelled      = "CANCELLED"
+	ticketStatusRefundEligible = "REFUND_ELIGIBLE" // Show of the ticket is cancelled or could not be moved to rescheduled show
+
+	ticketFilterUpcoming = "UPCOMING" // Tickets of shows which have not started
+	ticketFilterPast     = "PAST"     // Tickets of shows which have started
+
+	orderStatusPlaced    = "PLACED"
+	orderStatusPrepared  = "PREPARED"
+	orderStatusDelivered = "DELIVERED"
+	orderStatusCancelled = "CANCELLED" // Ticket or show of the order is cancelled, items are returned to stock and order is refund eligible
 )
 
 type Theatre struct {
 	// Represents a theatre structure
-	TheatreId       string `json:"theatreId"`
-	MovieHallNos    int    `json:"movieHallNos"`    // No's of movie hall available in theatre
-	TicketsPerShow  int    `json:"ticketsPerShow"`  // No's of seat per movie hall i.e. max no's of tickets per show can be sold
-	TicketWindowNos int    `json:"ticketWindowNos"` // No's of ticket windows
-	RecordType      int    `json:"RecordType"`      // 3 for theatre
+	TheatreId         string          `json:"theatreId"`
+	MovieHallNos      int             `json:"movieHallNos"`      // No's of movie hall available in theatre
+	TicketsPerShow    int             `json:"ticketsPerShow"`    // No's of seat per movie hall which is not registered separately, see MovieHall
+	TicketWindowNos   int             `json:"ticketWindowNos"`   // No's of ticket windows
+	OwnerMspId        string          `json:"ownerMspId"`        // MSP id of the organisation owning the theatre
+	OwnerId           string          `json:"ownerId"`           // Client identity which registered the theatre or received its ownership
+	TimeZone          string          `json:"timeZone"`          // IANA time zone of the theatre e.g. Asia/Kolkata, show dates and times are in this time zone
+	SalesOpenDays     int             `json:"salesOpenDays"`     // Booking opens these many days before show starts, 0 for no limit
+	SalesCloseMinutes int             `json:"salesCloseMinutes"` // Booking closes these many minutes after show starts
+	TransferRules     TransferRules   `json:"transferRules"`     // Rules for transferring tickets between customers
+	PendingTransfer   *TicketTransfer `json:"pendingTransfer"`   // Ownership transfer waiting for acceptance of the new owner, nil if none
+	RecordType        int             `json:"RecordType"`        // 3 for theatre
+}
+
+type TransferRules struct {
+	// Represents rules for transferring tickets of a theatre, by default tickets can be transferred only before
+	// the show starts and before any cafeteria item is redeemed against the ticket
+	AllowAfterShowStart  bool `json:"allowAfterShowStart"`
+	AllowAfterRedemption bool `json:"allowAfterRedemption"`
+	RequireAcceptance    bool `json:"requireAcceptance"` // Transfer completes only when the receiving client accepts it
+}
+
+type MovieHall struct {
+	// Represents a movie hall of a theatre
+	TheatreId             string   `json:"theatreId"`
+	MovieHallNo           int      `json:"movieHallNo"`
+	Capacity              int      `json:"capacity"`              // No's of seat i.e. max no's of tickets per show can be sold
+	ScreenType            string   `json:"screenType"`            // 2D, 3D or IMAX
+	AccessibilityFeatures []string `json:"accessibilityFeatures"` // e.g. wheelchair access, hearing loop, audio description
+	RecordType            int      `json:"recordType"`            // 6 for movie hall
 }
 
 type Show struct {
-	TheatreId     string `json:"theatreId"`
-	MovieHallNo   int    `json:"movieHallNo"`
-	ShowId        string `json:"showId"`
-	ShowName      string `json:"showName"`
-	ShowDate      string `json:"showDate"`
-	ShowStartDate string `json:"showStartDate"`
-	ShowEndDate   string `json:"showEndDate"`
-	ShowTime      string `json:"showTime"`
-	RecordType    int    `json:"recordType"` // 1 for show
+	TheatreId             string           `json:"theatreId"`
+	MovieHallNo           int              `json:"movieHallNo"`
+	ShowId                string           `json:"showId"`
+	ShowName              string           `json:"showName"`
+	ShowDate              string           `json:"showDate"`
+	ShowStartDate         string           `json:"showStartDate"`
+	ShowEndDate           string           `json:"showEndDate"`
+	ShowTime              string           `json:"showTime"`              // Show time in HH:MM 24 hour format
+	RuntimeMinutes        int              `json:"runtimeMinutes"`        // Runtime of the movie
+	CleaningBufferMinutes int              `json:"cleaningBufferMinutes"` // Time required to clean the movie hall after the show
+	RequiredScreenType    string           `json:"requiredScreenType"`    // Optional, screen type the movie hall should have e.g. 3D, IMAX
+	Prices                map[string]int64 `json:"prices"`                // Optional, price per seat by seat type, theatre's price list is used for missing seat types
+	SalesCloseAt          string           `json:"salesCloseAt"`          // Time when sales of the show close in UTC, in RFC3339 format. Set by chaincode
+	Status                string           `json:"status"`                // SCHEDULED or CANCELLED
+	RecordType            int              `json:"recordType"`            // 1 for show
+}
+
+type ShowSlot struct {
+	// Identifies a show by its theatre, date, time and movie hall
+	TheatreId   string `json:"theatreId"`
+	ShowDate    string `json:"showDate"`
+	ShowTime    string `json:"showTime"`
+	MovieHallNo int    `json:"movieHallNo"`
+}
+
+type ShowUpdate struct {
+	TheatreId             string           `json:"theatreId"`
+	ShowDate              string           `json:"showDate"`
+	ShowTime              string           `json:"showTime"`
+	MovieHallNo           int              `json:"movieHallNo"`
+	ShowName              string           `json:"showName"`              // Optional, show name is not changed if empty
+	RuntimeMinutes        *int             `json:"runtimeMinutes"`        // Optional, runtime is not changed if not provided
+	CleaningBufferMinutes *int             `json:"cleaningBufferMinutes"` // Optional, cleaning buffer is not changed if not provided
+	Prices                map[string]int64 `json:"prices"`                // Optional, prices are not changed if not provided
+}
+
+type ShowReschedule struct {
+	TheatreId      string `json:"theatreId"`
+	ShowDate       string `json:"showDate"`
+	ShowTime       string `json:"showTime"`
+	MovieHallNo    int    `json:"movieHallNo"`
+	NewShowDate    string `json:"newShowDate"`    // Optional, show date is not changed if empty
+	NewShowTime    string `json:"newShowTime"`    // Optional, show time is not changed if empty
+	NewMovieHallNo int    `json:"newMovieHallNo"` // Optional, movie hall is not changed if 0
+	MoveTickets    bool   `json:"moveTickets"`    // Move sold tickets to rescheduled show, otherwise mark them refund eligible
 }
 
 type ShowSearchQuery struct {
@@ -42,26 +194,388 @@ type SeatAvailabilityQuery struct {
 	MovieHallNo int    `json:"movieHallNo"`
 }
 
-type Cafeteria struct {
-	SodaBottleQuantity int `json:"sodaBottleQuantity"` // Soda bottle quantity available in cafeteria
+type Seat struct {
+	Row      string `json:"row"`      // Row label e.g. A, B, C
+	SeatNo   int    `json:"seatNo"`   // Seat number within the row
+	SeatType string `json:"seatType"` // Type of seat i.e. standard, premium or recliner
+}
+
+type SeatMap struct {
+	// Represents the seat layout of a movie hall
+	TheatreId   string `json:"theatreId"`
+	MovieHallNo int    `json:"movieHallNo"`
+	Seats       []Seat `json:"seats"`
+	RecordType  int    `json:"recordType"` // 4 for seat map
+}
+
+type PriceBand struct {
+	// Represents prices of shows on given weekdays starting in a time band
+	MovieHallNo int              `json:"movieHallNo"` // 0 for every movie hall of the theatre
+	Weekdays    []string         `json:"weekdays"`    // e.g. Monday, Tuesday. Empty for every day
+	StartTime   string           `json:"startTime"`   // Shows starting at or after this time, in HH:MM format
+	EndTime     string           `json:"endTime"`     // Shows starting before this time, in HH:MM format
+	Prices      map[string]int64 `json:"prices"`      // Price per seat by seat type
+}
+
+type PriceList struct {
+	// Represents ticket prices of a theatre. All prices are in smallest unit of theatre's currency e.g. paise
+	TheatreId  string      `json:"theatreId"`
+	PriceBands []PriceBand `json:"priceBands"` // First matching price band having the seat type is used
+	RecordType int         `json:"recordType"` // 7 for price list
+}
+
+type SoldSeat struct {
+	TicketId  string `json:"ticketId"`  // Ticket which holds the seat, empty while seat is only held
+	HoldId    string `json:"holdId"`    // Seat hold which holds the seat, empty once seat is sold
+	ExpiresAt string `json:"expiresAt"` // Expiry of seat hold in RFC3339 format
+}
+
+type SeatHold struct {
+	// Represents seats held for a client until payment is confirmed
+	HoldId      string   `json:"holdId"`
+	TheatreId   string   `json:"theatreId"`
+	ShowId      string   `json:"showId"`
+	ShowDate    string   `json:"showDate"`
+	ShowTime    string   `json:"showTime"`
+	MovieHallNo int      `json:"movieHallNo"`
+	NoOfSeats   int      `json:"noOfSeats"`
+	Seats       []string `json:"seats"`      // Seats held e.g. A1, A2. Required if the movie hall has a seat map
+	OwnerMspId  string   `json:"ownerMspId"` // MSP id of the client which held the seats
+	OwnerId     string   `json:"ownerId"`    // Identity of the client which held the seats
+	ExpiresAt   string   `json:"expiresAt"`  // Transaction timestamp of Hold_seats plus seatHoldMinutes, in RFC3339 format
+	RecordType  int      `json:"recordType"` // 12 for seat hold
+}
+
+type SeatHoldCount struct {
+	// Represents seats of a show held by a seat hold
+	HoldId    string `json:"holdId"`
+	NoOfSeats int    `json:"noOfSeats"`
+	ExpiresAt string `json:"expiresAt"` // In RFC3339 format
+}
+
+type ShowSeatCount struct {
+	// Represents no. of seats sold for a show
+	TheatreId   string          `json:"theatreId"`
+	ShowDate    string          `json:"showDate"`
+	ShowTime    string          `json:"showTime"`
+	MovieHallNo int             `json:"movieHallNo"`
+	SoldSeats   int             `json:"soldSeats"`
+	Bookings    int             `json:"bookings"`   // No. of tickets booked for the show, including cancelled tickets
+	Holds       []SeatHoldCount `json:"holds"`      // Seat holds which are not yet confirmed or swept
+	RecordType  int             `json:"recordType"` // 5 for show seat count
+}
+
+type SeatAvailability struct {
+	AvailableSeats int    `json:"availableSeats"`
+	FreeSeats      []Seat `json:"freeSeats"` // Empty for movie halls without a seat map
+}
+
+type CafeteriaItem struct {
+	// Represents an item in cafeteria catalogue of a theatre
+	TheatreId  string `json:"theatreId"`
+	Sku        string `json:"sku"` // Stock keeping unit e.g. SODA, POPCORN_L, NACHOS
+	Name       string `json:"name"`
+	UnitPrice  int64  `json:"unitPrice"`  // In smallest unit of theatre's currency e.g. paise
+	Stock      int    `json:"stock"`      // Quantity available in cafeteria
+	RecordType int    `json:"recordType"` // 8 for cafeteria item
+}
+
+type LegacyCafeteria struct {
+	// Soda bottle stock of a theatre stored before the cafeteria catalogue
+	SodaBottleQuantity int `json:"sodaBottleQuantity"`
+}
+
+type CafeteriaCatalogue struct {
+	Items []CafeteriaItem `json:"items"`
 }
 
 type Ticket struct {
+	TicketId        string          `json:"ticketId"`
+	TheatreId       string          `json:"theatreId"`
+	ShowId          string          `json:"showId"`
+	ShowDate        string          `json:"showDate"`
+	ShowTime        string          `json:"showTime"`
+	ShowStartsAt    string          `json:"showStartsAt"` // Start of the show in UTC, in RFC3339 format. Set by chaincode, empty for tickets booked before it was stored
+	MovieHallNo     int             `json:"movieHallNo"`
+	NoOfSeats       int             `json:"noOfSeats"`
+	Seats           []string        `json:"seats"`           // Seats booked e.g. A1, A2. Required if the movie hall has a seat map
+	LuckyNo         int             `json:"luckyNo"`         // Drawn by chaincode once seed of show's lucky draw is revealed, 0 until then
+	BookingTxId     string          `json:"bookingTxId"`     // Transaction which booked the ticket, input of the lucky draw
+	BookingNo       int             `json:"bookingNo"`       // Sequence no. of the ticket among tickets booked for the show
+	OwnerMspId      string          `json:"ownerMspId"`      // MSP id of the client which booked the ticket
+	OwnerId         string          `json:"ownerId"`         // Identity of the client which booked the ticket
+	CustomerHash    string          `json:"customerHash"`    // SHA-256 hash of customer details stored in private data collection
+	TotalPrice      int64           `json:"totalPrice"`      // Sum of price of every seat, computed by chaincode
+	PriceBreakdown  []TicketPrice   `json:"priceBreakdown"`  // Price per seat type, computed by chaincode
+	Status          string          `json:"status"`          // BOOKED or CANCELLED
+	PendingTransfer *TicketTransfer `json:"pendingTransfer"` // Transfer waiting for acceptance of the receiving client, nil if none
+	RecordType      int             `json:"recordType"`      // 2 for ticket
+}
+
+type TicketTransfer struct {
+	// Represents a ticket or theatre transfer which is not yet accepted
+	ToMspId     string `json:"toMspId"`     // MSP id of the receiving client
+	ToId        string `json:"toId"`        // Identity of the receiving client
+	RequestedAt string `json:"requestedAt"` // Transaction timestamp in RFC3339 format
+}
+
+type TicketPrice struct {
+	// Represents price of seats of one seat type in a ticket
+	SeatType  string `json:"seatType"`
+	NoOfSeats int    `json:"noOfSeats"`
+	UnitPrice int64  `json:"unitPrice"`
+	Amount    int64  `json:"amount"`
+}
+
+type CustomerDetails struct {
+	// Customer's personal information, stored only in private data collection
+	TicketId string `json:"ticketId"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+	Salt     string `json:"salt"` // Random value provided by client so that hash on ledger can not be guessed, at least minCustomerSaltLength characters
+}
+
+type OrderItem struct {
+	Sku       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int64  `json:"unitPrice"` // Computed by chaincode from cafeteria catalogue
+	Amount    int64  `json:"amount"`    // Computed by chaincode
+}
+
+type CafeteriaOrder struct {
+	// Represents food ordered with a ticket, delivered to the seats of the ticket
+	OrderId     string      `json:"orderId"`
+	TicketId    string      `json:"ticketId"`
+	TheatreId   string      `json:"theatreId"`
+	ShowDate    string      `json:"showDate"`
+	ShowTime    string      `json:"showTime"`
+	MovieHallNo int         `json:"movieHallNo"`
+	Seats       []string    `json:"seats"`
+	Items       []OrderItem `json:"items"`
+	TotalPrice  int64       `json:"totalPrice"`
+	Status      string      `json:"status"`     // PLACED, PREPARED, DELIVERED or CANCELLED
+	RecordType  int         `json:"recordType"` // 9 for cafeteria order
+}
+
+type CafeteriaOrderList struct {
+	Orders []CafeteriaOrder `json:"orders"`
+}
+
+type LuckyDraw struct {
+	// Represents the lucky draw of a show. Theatre commits to a secret seed and reveals it after sales close, lucky no.
+	// of a ticket booked after the commitment is derived from the seed and the transaction which booked the ticket
+	TheatreId      string `json:"theatreId"`
+	ShowDate       string `json:"showDate"`
+	ShowTime       string `json:"showTime"`
+	MovieHallNo    int    `json:"movieHallNo"`
+	SeedCommitment string `json:"seedCommitment"` // Hex encoded SHA-256 hash of the seed
+	Seed           string `json:"seed"`           // Empty until revealed
+	CommittedAt    string `json:"committedAt"`    // Transaction timestamp in RFC3339 format
+	RevealedAt     string `json:"revealedAt"`     // Transaction timestamp in RFC3339 format
+	FromBookingNo  int    `json:"fromBookingNo"`  // First booking no. of the show after the commitment, earlier tickets get no lucky no.
+	RecordType     int    `json:"recordType"`     // 11 for lucky draw
+}
+
+type PromotionConditions struct {
+	// Conditions a ticket should meet for a promotion, conditions which are not set are not checked
+	ShowId            string   `json:"showId"`
+	Weekdays          []string `json:"weekdays"`          // Weekdays of the show e.g. Tuesday
+	MinSeats          int      `json:"minSeats"`          // Min no. of seats in the ticket
+	EveryNthBooking   int      `json:"everyNthBooking"`   // Booking no. of the ticket is a multiple of this no.
+	MaxBookingNo      int      `json:"maxBookingNo"`      // Ticket is one of the first these many bookings of the show
+	LuckyNoMultipleOf int      `json:"luckyNoMultipleOf"` // Lucky no. of the ticket is a multiple of this no.
+}
+
+type Promotion struct {
+	// Represents a promotion of a theatre giving a cafeteria item against eligible tickets
+	PromotionId    string              `json:"promotionId"`
+	TheatreId      string              `json:"theatreId"`
+	Description    string              `json:"description"`
+	Sku            string              `json:"sku"`       // Cafeteria item given as reward
+	StartDate      string              `json:"startDate"` // Optional, first show date of the promotion
+	EndDate        string              `json:"endDate"`   // Optional, last show date of the promotion
+	Conditions     PromotionConditions `json:"conditions"`
+	MaxRedemptions int                 `json:"maxRedemptions"` // 0 for no limit
+	Redemptions    int                 `json:"redemptions"`    // No. of rewards given
+	Active         bool                `json:"active"`
+	RecordType     int                 `json:"recordType"` // 10 for promotion
+}
+
+type PromotionList struct {
+	Promotions []Promotion `json:"promotions"`
+}
+
+type Redemption struct {
+	// Represents a cafeteria item given against a ticket
+	TicketId    string `json:"ticketId"`
+	TheatreId   string `json:"theatreId"`
+	Sku         string `json:"sku"`
+	PromotionId string `json:"promotionId"` // Promotion under which the item is given
+}
+
+type RichQuery struct {
+	// Represents a CouchDB rich query
+	Selector map[string]interface{} `json:"selector"`
+	UseIndex []string               `json:"use_index"` // Design document and name of the index
+}
+
+type ShowSearchResult struct {
+	ShowList []Show `json:"showList"`
+}
+
+type PaginatedShowSearchResult struct {
+	ShowList            []Show `json:"showList"`
+	Bookmark            string `json:"bookmark"`            // Bookmark to fetch the next page
+	FetchedRecordsCount int32  `json:"fetchedRecordsCount"` // No. of records fetched in this page
+}
+
+type TicketHistoryEntry struct {
+	// Represents a version of a ticket
+	TxId      string  `json:"txId"`
+	Timestamp string  `json:"timestamp"` // Transaction timestamp in RFC3339 format
+	IsDelete  bool    `json:"isDelete"`
+	Value     *Ticket `json:"value"` // Nil if the ticket is deleted in this version
+}
+
+type TicketHistory struct {
+	TicketId string               `json:"ticketId"`
+	History  []TicketHistoryEntry `json:"history"` // Oldest version first
+}
+
+type ShowHistoryEntry struct {
+	// Represents a version of a show
+	TxId      string `json:"txId"`
+	Timestamp string `json:"timestamp"` // Transaction timestamp in RFC3339 format
+	IsDelete  bool   `json:"isDelete"`  // True if the show is rescheduled to another slot in this version
+	Value     *Show  `json:"value"`     // Nil if the show is deleted in this version
+}
+
+type ShowHistory struct {
+	TheatreId   string             `json:"theatreId"`
+	ShowDate    string             `json:"showDate"`
+	ShowTime    string             `json:"showTime"`
+	MovieHallNo int                `json:"movieHallNo"`
+	History     []ShowHistoryEntry `json:"history"` // Oldest version first
+}
+
+type CafeteriaItemHistoryEntry struct {
+	// Represents a version of a cafeteria item
+	TxId      string         `json:"txId"`
+	Timestamp string         `json:"timestamp"` // Transaction timestamp in RFC3339 format
+	IsDelete  bool           `json:"isDelete"`
+	Value     *CafeteriaItem `json:"value"` // Nil if the item is deleted in this version
+}
+
+type CafeteriaItemHistory struct {
+	TheatreId string                      `json:"theatreId"`
+	Sku       string                      `json:"sku"`
+	History   []CafeteriaItemHistoryEntry `json:"history"` // Oldest version first
+}
+
+type PaginatedTicketList struct {
+	Tickets             []Ticket `json:"tickets"`
+	Bookmark            string   `json:"bookmark"`            // Bookmark to fetch the next page
+	FetchedRecordsCount int32    `json:"fetchedRecordsCount"` // No. of records fetched in this page
+}
+
+type Event struct {
+	// Represents payload of a chaincode event
+	Version   string      `json:"version"`   // Version of event payload schema
+	EventType string      `json:"eventType"` // Name of the event
+	TxId      string      `json:"txId"`
+	Timestamp string      `json:"timestamp"` // Transaction timestamp in RFC3339 format
+	Data      interface{} `json:"data"`      // One of the event data structures below
+}
+
+type TicketEventData struct {
+	// Data of TicketBooked and TicketCancelled events
+	TicketId          string   `json:"ticketId"`
+	TheatreId         string   `json:"theatreId"`
+	ShowId            string   `json:"showId"`
+	ShowDate          string   `json:"showDate"`
+	ShowTime          string   `json:"showTime"`
+	MovieHallNo       int      `json:"movieHallNo"`
+	NoOfSeats         int      `json:"noOfSeats"`
+	Seats             []string `json:"seats"`
+	TotalPrice        int64    `json:"totalPrice"`
+	CancelledOrderIds []string `json:"cancelledOrderIds,omitempty"` // Set for TicketCancelled, cafeteria orders cancelled with the ticket
+}
+
+type TicketTransferEventData struct {
+	// Data of TicketTransferRequested, TicketTransferred and TicketTransferCancelled events
 	TicketId    string `json:"ticketId"`
 	TheatreId   string `json:"theatreId"`
 	ShowId      string `json:"showId"`
 	ShowDate    string `json:"showDate"`
 	ShowTime    string `json:"showTime"`
 	MovieHallNo int    `json:"movieHallNo"`
-	NoOfSeats   int    `json:"noOfSeats"`
-	LuckyNo     int    `json:"luckyNo"`
-	RecordType  int    `json:"recordType"` // 2 for ticket
+	FromMspId   string `json:"fromMspId"` // MSP id of the owner transferring the ticket
+	ToMspId     string `json:"toMspId"`   // MSP id of the receiving client
 }
 
-type SodaBottleReplacement struct {
-	TicketId string `json:"ticketId"`
+type TheatreTransferEventData struct {
+	// Data of TheatreTransferRequested, TheatreTransferred and TheatreTransferCancelled events
+	TheatreId string `json:"theatreId"`
+	FromMspId string `json:"fromMspId"` // MSP id of the owner transferring the theatre, empty for theatres assigned their first owner
+	ToMspId   string `json:"toMspId"`   // MSP id of the new owner
 }
 
-type ShowSearchResult struct {
-	ShowList []Show `json:"showList"`
+type CafeteriaItemRedeemedEventData struct {
+	TicketId    string `json:"ticketId"`
+	TheatreId   string `json:"theatreId"`
+	Sku         string `json:"sku"`
+	PromotionId string `json:"promotionId"`
+	Stock       int    `json:"stock"` // Quantity of the item left in cafeteria
+}
+
+type SodaBottleReplacedEventData struct {
+	TicketId           string `json:"ticketId"`
+	TheatreId          string `json:"theatreId"`
+	SodaBottleQuantity int    `json:"sodaBottleQuantity"` // Soda bottle quantity left in cafeteria
+}
+
+type ShowRegisteredEventData struct {
+	TheatreId     string `json:"theatreId"`
+	MovieHallNo   int    `json:"movieHallNo"`
+	ShowId        string `json:"showId"`
+	ShowName      string `json:"showName"`
+	ShowStartDate string `json:"showStartDate"`
+	ShowEndDate   string `json:"showEndDate"`
+	ShowTime      string `json:"showTime"`
+}
+
+type ShowEventData struct {
+	// Data of ShowUpdated, ShowRescheduled and ShowCancelled events
+	TheatreId               string   `json:"theatreId"`
+	ShowId                  string   `json:"showId"`
+	ShowName                string   `json:"showName"`
+	ShowDate                string   `json:"showDate"`
+	ShowTime                string   `json:"showTime"`
+	MovieHallNo             int      `json:"movieHallNo"`
+	PreviousShowDate        string   `json:"previousShowDate,omitempty"`    // Set for ShowRescheduled
+	PreviousShowTime        string   `json:"previousShowTime,omitempty"`    // Set for ShowRescheduled
+	PreviousMovieHallNo     int      `json:"previousMovieHallNo,omitempty"` // Set for ShowRescheduled
+	MovedTicketIds          []string `json:"movedTicketIds"`
+	RefundEligibleTicketIds []string `json:"refundEligibleTicketIds"`
+	CancelledOrderIds       []string `json:"cancelledOrderIds,omitempty"` // Cafeteria orders of refund eligible tickets, items are returned to stock
+}
+
+type CafeteriaOrderEventData struct {
+	// Data of CafeteriaOrderPlaced and CafeteriaOrderUpdated events
+	OrderId     string   `json:"orderId"`
+	TicketId    string   `json:"ticketId"`
+	TheatreId   string   `json:"theatreId"`
+	ShowDate    string   `json:"showDate"`
+	ShowTime    string   `json:"showTime"`
+	MovieHallNo int      `json:"movieHallNo"`
+	Seats       []string `json:"seats"`
+	Status      string   `json:"status"`
+}
+
+type CafeteriaInventoryAddedEventData struct {
+	TheatreId     string `json:"theatreId"`
+	Sku           string `json:"sku"`
+	QuantityAdded int    `json:"quantityAdded"` // Negative for stock removed e.g. damaged or expired items
+	Stock         int    `json:"stock"`         // Quantity of the item in cafeteria after addition
 }
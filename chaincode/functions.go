@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -27,6 +29,26 @@ func (s *MovieTicket) Register_theatre(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("Invalid json input: %s, Error: %s", theatreStr, err.Error())
 	}
 
+	// Only theatre admins can register a theatre
+	if err := checkClientRole(ctx, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to register theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
+		return err
+	}
+
+	// Theatre should declare its IANA time zone
+	if theatre.TimeZone == "" {
+		log.Errorf("Time zone is not provided for theatre id: %s", theatre.TheatreId)
+		return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+	} else if _, err := getTheatreLocation(theatre); err != nil {
+		log.Errorf("Invalid time zone: %s for theatre id: %s", theatre.TimeZone, theatre.TheatreId)
+		return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+	}
+
+	if theatre.SalesOpenDays < 0 || theatre.SalesCloseMinutes < 0 {
+		log.Errorf("Invalid sales window, sales open days: %d, sales close minutes: %d", theatre.SalesOpenDays, theatre.SalesCloseMinutes)
+		return fmt.Errorf("Invalid sales window, sales open days: %d, sales close minutes: %d", theatre.SalesOpenDays, theatre.SalesCloseMinutes)
+	}
+
 	// Check whether theatre id already registered or not
 	if data, err := ctx.GetStub().GetState(theatre.TheatreId); err != nil {
 		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatre.TheatreId, err.Error())
@@ -35,6 +57,19 @@ func (s *MovieTicket) Register_theatre(ctx contractapi.TransactionContextInterfa
 		log.Errorf("Theatre with theatre id %s already registered", theatre.TheatreId)
 		return fmt.Errorf("Theatre with theatre id %s already registered", theatre.TheatreId)
 	}
+	// Theatre is owned by the organisation of the registering client
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		log.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+	}
+	clientId, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		log.Errorf("Failed to get id of client, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get id of client, Error: %s", err.Error())
+	}
+	theatre.OwnerMspId = mspId
+	theatre.OwnerId = clientId
 	theatre.RecordType = 3
 	// Theatre with the same theatre id is not registered.
 	theatreAsBytes, _ := json.Marshal(theatre)
@@ -44,292 +79,3109 @@ func (s *MovieTicket) Register_theatre(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("Failed to register theatre with theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
 	}
 
-	// Register cafeteria
-	cafeteria := new(Cafeteria)
-	cafeteriaAsBytes, _ := json.Marshal(cafeteria)
+	log.Infof("Theatre with theatre id: %s registered successfully !!", theatre.TheatreId)
+	return nil
+}
+
+/**
+	Method to transfer ownership of a theatre to another organisation. Transfer stays pending until a theatre admin
+	with the new owner's identity accepts it, which proves the new owner is a member of the channel
+*/
+func (s *MovieTicket) Transfer_theatre_ownership(ctx contractapi.TransactionContextInterface, theatreId, newOwnerMspId, newOwnerId string) error {
+	log := logging.MustGetLogger(name)
+	if newOwnerMspId == "" || newOwnerId == "" {
+		log.Errorf("Invalid new owner MSP id: %s or owner id: %s", newOwnerMspId, newOwnerId)
+		return fmt.Errorf("Invalid new owner MSP id: %s or owner id: %s", newOwnerMspId, newOwnerId)
+	}
+
+	// Only theatre admins of the current owner can transfer the theatre
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to transfer theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	} else if theatre.OwnerMspId == newOwnerMspId {
+		log.Errorf("Theatre id %s is already owned by %s", theatreId, newOwnerMspId)
+		return fmt.Errorf("INVALID_NEW_OWNER")
+	}
+
+	// A new transfer replaces any pending transfer
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	theatre.PendingTransfer = &TicketTransfer{ToMspId: newOwnerMspId, ToId: newOwnerId, RequestedAt: txTime.Format(time.RFC3339)}
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to request transfer of theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to request transfer of theatre id: %s, Error: %s", theatreId, err.Error())
+	}
 
-	if err := ctx.GetStub().PutState("cafeteria_"+theatre.TheatreId, cafeteriaAsBytes); err != nil {
-		log.Errorf("Failed to register cafeteria with theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
-		return fmt.Errorf("Failed to register cafeteria with theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
+	if err := setEvent(ctx, theatreTransferRequestedEvent, &TheatreTransferEventData{TheatreId: theatreId, FromMspId: theatre.OwnerMspId, ToMspId: newOwnerMspId}); err != nil {
+		log.Errorf("Failed to set theatre transfer requested event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set theatre transfer requested event for theatre id: %s, Error: %s", theatreId, err.Error())
 	}
 
-	log.Infof("Theatre with theatre id: %s registered successfully !!", theatre.TheatreId)
+	log.Infof("Transfer of theatre id: %s to %s requested successfully !!", theatreId, newOwnerMspId)
 	return nil
 }
 
 /**
-	Method to register a show
+	Method to accept a pending ownership transfer of a theatre, allowed only for a theatre admin with the new owner's identity
 */
-func (s *MovieTicket) Register_show(ctx contractapi.TransactionContextInterface, showStr string) error {
+func (s *MovieTicket) Accept_theatre_ownership(ctx contractapi.TransactionContextInterface, theatreId string) error {
 	log := logging.MustGetLogger(name)
-	var err error
-	var data []byte
-	show := new(Show)
-	if err = json.Unmarshal([]byte(showStr), &show); err != nil {
-		log.Errorf("Invalid json input: %s, Error: %s", showStr, err.Error())
-		return fmt.Errorf("Invalid json input: %s, Error: %s", showStr, err.Error())
+
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if theatre.PendingTransfer == nil {
+		log.Errorf("Theatre id %s does not have a pending transfer", theatreId)
+		return fmt.Errorf("NO_PENDING_TRANSFER")
 	}
 
-	// Check whether provided theatre id and movie hall id is valid or not
-	if data, err = ctx.GetStub().GetState(show.TheatreId); err != nil {
-		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", show.TheatreId, err.Error())
-		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", show.TheatreId, err.Error())
-	} else if data == nil {
-		log.Errorf("Theatre with theatre id %s does not exist", show.TheatreId)
-		return fmt.Errorf("Theatre with theatre id %s does not exist", show.TheatreId)
-	} else {
-		theatre := new(Theatre)
-		_ = json.Unmarshal([]byte(data), &theatre)
-		if show.MovieHallNo < 1 || show.MovieHallNo > theatre.MovieHallNos {
-			log.Errorf("Movie hall no %d in theatre %s does not exist.", show.MovieHallNo, theatre.TheatreId)
-			return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", show.MovieHallNo, theatre.TheatreId)
-		}
+	if err = checkClientRole(ctx, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to accept transfer of theatre id %s, Error: %s", theatreId, err.Error())
+		return err
+	} else if err = checkClientIdentity(ctx, theatre.PendingTransfer.ToMspId, theatre.PendingTransfer.ToId); err != nil {
+		log.Errorf("Client is not authorised to accept transfer of theatre id %s, Error: %s", theatreId, err.Error())
+		return err
 	}
 
-	var showStartDate, showEndDate time.Time
-	if showStartDate, err = time.Parse("2006-01-02", show.ShowStartDate); err != nil {
-		// Start date parsing issue
-		log.Errorf("Invalid show start date: %s, Error: %s", show.ShowStartDate, err.Error())
-		return fmt.Errorf("Invalid show start date: %s, Error: %s", show.ShowStartDate, err.Error())
+	previousOwnerMspId := theatre.OwnerMspId
+	theatre.OwnerMspId = theatre.PendingTransfer.ToMspId
+	theatre.OwnerId = theatre.PendingTransfer.ToId
+	theatre.PendingTransfer = nil
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to transfer theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to transfer theatre with theatre id: %s, Error: %s", theatreId, err.Error())
 	}
 
-	if showEndDate, err = time.Parse("2006-01-02", show.ShowEndDate); err != nil {
-		// End date parsing issue
-		log.Errorf("Invalid show end date: %s, Error: %s", show.ShowEndDate, err.Error())
-		return fmt.Errorf("Invalid show end date: %s, Error: %s", show.ShowEndDate, err.Error())
+	if err := setEvent(ctx, theatreTransferredEvent, &TheatreTransferEventData{TheatreId: theatreId, FromMspId: previousOwnerMspId, ToMspId: theatre.OwnerMspId}); err != nil {
+		log.Errorf("Failed to set theatre transferred event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set theatre transferred event for theatre id: %s, Error: %s", theatreId, err.Error())
 	}
 
-	if showEndDate.Before(showStartDate) {
-		// End date < Start date
-		log.Errorf("Invalid show start & end dates. Start date: %s, End date: %s", show.ShowStartDate, show.ShowEndDate)
-		return fmt.Errorf("Invalid show start & end dates. Start date: %s, End date: %s", show.ShowStartDate, show.ShowEndDate)
+	log.Infof("Theatre with theatre id: %s transferred from %s to %s successfully !!", theatreId, previousOwnerMspId, theatre.OwnerMspId)
+	return nil
+}
+
+/**
+	Method to cancel a pending ownership transfer of a theatre, allowed for theatre admins of the current owner
+*/
+func (s *MovieTicket) Cancel_theatre_transfer(ctx contractapi.TransactionContextInterface, theatreId string) error {
+	log := logging.MustGetLogger(name)
+
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if theatre.PendingTransfer == nil {
+		log.Errorf("Theatre id %s does not have a pending transfer", theatreId)
+		return fmt.Errorf("NO_PENDING_TRANSFER")
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to cancel transfer of theatre id %s, Error: %s", theatreId, err.Error())
+		return err
 	}
 
-	for d := showStartDate; !d.After(showEndDate); d = d.AddDate(0, 0, 1) {
-		// Register show for each day
-		key, _ := getCompositeKey(ctx, showKeyIndex, show.TheatreId, d.Format("2006-01-02"), show.ShowTime, strconv.Itoa(show.MovieHallNo))
-		// Check whether any existing show exist on same date and time
-		if data, err = ctx.GetStub().GetState(key); err != nil {
-			log.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
-			return fmt.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
-		} else if data != nil {
-			log.Errorf("Show already exist on date: %s and time %s", d.Format("2006-01-02"), show.ShowTime)
-			return fmt.Errorf("Show already exist on date: %s and time %s", d.Format("2006-01-02"), show.ShowTime)
-		} else {
-			// register the show
-			show.ShowDate = d.Format("2006-01-02")
-			show.RecordType = 1
-			showAsBytes, _ := json.Marshal(show)
-			if err := ctx.GetStub().PutState(key, showAsBytes); err != nil {
-				log.Errorf("Failed to register show with show id: %s, Error: %s", show.ShowId, err.Error())
-				return fmt.Errorf("Failed to register show with show id: %s, Error: %s", show.ShowId, err.Error())
-			}
-		}
+	toMspId := theatre.PendingTransfer.ToMspId
+	theatre.PendingTransfer = nil
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to cancel transfer of theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to cancel transfer of theatre id: %s, Error: %s", theatreId, err.Error())
 	}
 
-	log.Infof("Show with show id: %s registered successfully !!", show.ShowId)
+	if err := setEvent(ctx, theatreTransferCancelledEvent, &TheatreTransferEventData{TheatreId: theatreId, FromMspId: theatre.OwnerMspId, ToMspId: toMspId}); err != nil {
+		log.Errorf("Failed to set theatre transfer cancelled event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set theatre transfer cancelled event for theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	log.Infof("Transfer of theatre id: %s cancelled successfully !!", theatreId)
 	return nil
 }
 
 /**
-	Method to add soda bottles to cafeteria's inventory
+	Method to assign an owner to a theatre registered before theatre ownership was recorded. A theatre can be
+	assigned only once, later changes of ownership go through Transfer_theatre_ownership
 */
-func (s *MovieTicket) Add_cafeteria_inventory(ctx contractapi.TransactionContextInterface, theatreId string, sodaBottleQuantity int) error {
+func (s *MovieTicket) Assign_theatre_owner(ctx contractapi.TransactionContextInterface, theatreId, ownerMspId, ownerId string) error {
 	log := logging.MustGetLogger(name)
+	if ownerMspId == "" || ownerId == "" {
+		log.Errorf("Invalid owner MSP id: %s or owner id: %s", ownerMspId, ownerId)
+		return fmt.Errorf("Invalid owner MSP id: %s or owner id: %s", ownerMspId, ownerId)
+	}
 
-	// Get the cafeteria record
-	if data, err := ctx.GetStub().GetState("cafeteria_" + theatreId); err != nil {
-		log.Errorf("Failed to get state for theatre cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
-		return fmt.Errorf("Failed to get state for theatre cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
-	} else if data == nil {
+	// Only channel admins can assign owners of legacy theatres
+	if err := checkClientRole(ctx, roleChannelAdmin); err != nil {
+		log.Errorf("Client is not authorised to assign owner of theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	}
+
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
 		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
 		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
-	} else {
-		// Add soda bottle quantity
-		cafeteria := new(Cafeteria)
-		_ = json.Unmarshal([]byte(data), &cafeteria)
-		cafeteria.SodaBottleQuantity += sodaBottleQuantity
-		cafeteriaAsBytes, _ := json.Marshal(cafeteria)
-		if err := ctx.GetStub().PutState("cafeteria_"+theatreId, cafeteriaAsBytes); err != nil {
-			log.Errorf("Failed to register cafeteria with theatre id: %s, Error: %s", theatreId, err.Error())
-			return fmt.Errorf("Failed to register cafeteria with theatre id: %s, Error: %s", theatreId, err.Error())
-		}
+	} else if theatre.OwnerMspId != "" {
+		log.Errorf("Theatre with theatre id %s is already owned by %s", theatreId, theatre.OwnerMspId)
+		return fmt.Errorf("THEATRE_ALREADY_OWNED: %s", theatreId)
+	}
 
-		log.Infof("Inventry added to cafeteria successfully for theatre id: %s", theatreId)
-		return nil
+	theatre.OwnerMspId = ownerMspId
+	theatre.OwnerId = ownerId
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to assign owner of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to assign owner of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
 	}
+
+	if err := setEvent(ctx, theatreTransferredEvent, &TheatreTransferEventData{TheatreId: theatreId, ToMspId: ownerMspId}); err != nil {
+		log.Errorf("Failed to set theatre transferred event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set theatre transferred event for theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	log.Infof("Theatre with theatre id: %s assigned to %s successfully !!", theatreId, ownerMspId)
+	return nil
 }
 
 /**
-	Method to get list of shows using rich query
+	Method to set sales window of a theatre, sales close time of its shows which may be on sale is updated too
 */
-func (s *MovieTicket) Get_shows(ctx contractapi.TransactionContextInterface, showSearchQueryStr string) (*ShowSearchResult, error) {
+func (s *MovieTicket) Set_sales_window(ctx contractapi.TransactionContextInterface, theatreId string, salesOpenDays, salesCloseMinutes int) error {
 	log := logging.MustGetLogger(name)
-	showSearchQuery := new(ShowSearchQuery)
-	if err := json.Unmarshal([]byte(showSearchQueryStr), &showSearchQuery); err != nil {
-		log.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
-		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
+	if salesOpenDays < 0 || salesCloseMinutes < 0 {
+		log.Errorf("Invalid sales window, sales open days: %d, sales close minutes: %d", salesOpenDays, salesCloseMinutes)
+		return fmt.Errorf("Invalid sales window, sales open days: %d, sales close minutes: %d", salesOpenDays, salesCloseMinutes)
 	}
-	
-	// Create rich query string
-	queryString := CreateShowSearchQuery(showSearchQuery)
-	log.Info("Querying chaincode with query string: %s", queryString)
-	
-	// Execute couchdb rich query to get list of all available shows
+
+	// Only theatre admins of the owning organisation can change the sales window
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to set sales window of theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	}
+
+	// Shows which are on sale with the earlier or the new sales window get the new sales close time. Show dates are in
+	// theatre's time zone, so shows of the day before are included too
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	maxSalesCloseMinutes := theatre.SalesCloseMinutes
+	if salesCloseMinutes > maxSalesCloseMinutes {
+		maxSalesCloseMinutes = salesCloseMinutes
+	}
+	fromShowDate := txTime.Add(-time.Duration(maxSalesCloseMinutes)*time.Minute).AddDate(0, 0, -1).Format("2006-01-02")
+
+	theatre.SalesOpenDays = salesOpenDays
+	theatre.SalesCloseMinutes = salesCloseMinutes
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to set sales window of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set sales window of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	queryString := CreateTheatreShowsQuery(theatreId, fromShowDate)
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
 		log.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
-		return nil, fmt.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+		return fmt.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
 	}
+	defer resultsIterator.Close()
 
-	var shows []Show
-	var queryResult *queryresult.KV
 	for resultsIterator.HasNext() {
-		queryResult, err = resultsIterator.Next()
+		queryResult, err := resultsIterator.Next()
 		if err != nil {
-			return nil, fmt.Errorf("Got error: %s", err.Error())
+			return fmt.Errorf("Got error: %s", err.Error())
+		}
+		show := new(Show)
+		if err = json.Unmarshal(queryResult.Value, &show); err != nil {
+			return fmt.Errorf("Got error: %s", err.Error())
+		}
+		// Shows registered before show time was validated are left as they are
+		if err = setShowSalesCloseTime(theatre, show); err != nil {
+			continue
+		}
+		showAsBytes, _ := json.Marshal(show)
+		if err := ctx.GetStub().PutState(queryResult.Key, showAsBytes); err != nil {
+			log.Errorf("Failed to set sales close time of show id: %s, Error: %s", show.ShowId, err.Error())
+			return fmt.Errorf("Failed to set sales close time of show id: %s, Error: %s", show.ShowId, err.Error())
 		}
-		var show Show
-		_ = json.Unmarshal(queryResult.Value, &show)
-		shows = append(shows, show)
 	}
 
-	showSearchResult := new(ShowSearchResult)
-	if shows != nil {
-		showSearchResult.ShowList = shows
-	} else {
-		showSearchResult.ShowList = []Show{}
+	log.Infof("Sales window of theatre with theatre id: %s set successfully !!", theatreId)
+	return nil
+}
+
+/**
+	Method to set rules for transferring tickets of a theatre
+*/
+func (s *MovieTicket) Set_transfer_rules(ctx contractapi.TransactionContextInterface, theatreId string, allowAfterShowStart, allowAfterRedemption, requireAcceptance bool) error {
+	log := logging.MustGetLogger(name)
+
+	// Only theatre admins of the owning organisation can change the transfer rules
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to set transfer rules of theatre %s, Error: %s", theatreId, err.Error())
+		return err
 	}
 
-	return showSearchResult, nil
+	theatre.TransferRules.AllowAfterShowStart = allowAfterShowStart
+	theatre.TransferRules.AllowAfterRedemption = allowAfterRedemption
+	theatre.TransferRules.RequireAcceptance = requireAcceptance
+	theatreAsBytes, _ := json.Marshal(theatre)
+	if err := ctx.GetStub().PutState(theatreId, theatreAsBytes); err != nil {
+		log.Errorf("Failed to set transfer rules of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set transfer rules of theatre with theatre id: %s, Error: %s", theatreId, err.Error())
+	}
 
+	log.Infof("Transfer rules of theatre with theatre id: %s set successfully !!", theatreId)
+	return nil
 }
 
 /**
-	Method to get no. of available seats/ ticket
+	Method to register a movie hall of a theatre
 */
-func (s *MovieTicket) Get_seat_availability(ctx contractapi.TransactionContextInterface, seatAvailabilityQueryStr string) (int, error) {
+func (s *MovieTicket) Register_movie_hall(ctx contractapi.TransactionContextInterface, movieHallStr string) error {
 	log := logging.MustGetLogger(name)
-	query := new(SeatAvailabilityQuery)
-
-	if err := json.Unmarshal([]byte(seatAvailabilityQueryStr), &query); err != nil {
-		log.Errorf("Invalid json input: %s, Error: %s", seatAvailabilityQueryStr, err.Error())
-		return 0, fmt.Errorf("Invalid json input: %s, Error: %s", seatAvailabilityQueryStr, err.Error())
-	} else if query.TheatreId == "" || query.ShowId == "" || query.ShowDate == "" || query.ShowTime == "" || query.MovieHallNo < 1 {
-		log.Errorf("Invalid json input: %s", seatAvailabilityQueryStr)
-		return 0, fmt.Errorf("Invalid json input: %s", seatAvailabilityQueryStr)
+	movieHall := new(MovieHall)
+	if err := json.Unmarshal([]byte(movieHallStr), &movieHall); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", movieHallStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", movieHallStr, err.Error())
+	} else if movieHall.TheatreId == "" || movieHall.Capacity < 1 {
+		log.Errorf("Invalid json input: %s", movieHallStr)
+		return fmt.Errorf("Invalid json input: %s", movieHallStr)
+	} else if movieHall.ScreenType != screenType2D && movieHall.ScreenType != screenType3D && movieHall.ScreenType != screenTypeIMAX {
+		log.Errorf("Invalid screen type: %s", movieHall.ScreenType)
+		return fmt.Errorf("INVALID_SCREEN_TYPE: %s", movieHall.ScreenType)
 	}
-	
-	// Get no. of available seats
-	availableSeats, err := GetSeatAvailability(ctx, query.TheatreId, query.ShowId, query.ShowDate, query.ShowTime, query.MovieHallNo)
+
+	// Only theatre admins of the owning organisation can register a movie hall
+	theatre, err := getTheatre(ctx, movieHall.TheatreId)
 	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", movieHall.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", movieHall.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", movieHall.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", movieHall.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to register movie hall in theatre %s, Error: %s", movieHall.TheatreId, err.Error())
+		return err
+	} else if movieHall.MovieHallNo < 1 || movieHall.MovieHallNo > theatre.MovieHallNos {
+		log.Errorf("Movie hall no %d in theatre %s does not exist.", movieHall.MovieHallNo, theatre.TheatreId)
+		return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", movieHall.MovieHallNo, theatre.TheatreId)
+	}
+
+	// Seat map registered earlier should fit in the movie hall
+	if seatMap, err := getSeatMap(ctx, movieHall.TheatreId, movieHall.MovieHallNo); err != nil {
 		log.Errorf("Got error: %s", err.Error())
-		return availableSeats, fmt.Errorf("Error: %s", err.Error())
+		return fmt.Errorf("Got error: %s", err.Error())
+	} else if seatMap != nil && len(seatMap.Seats) > movieHall.Capacity {
+		log.Errorf("Seat map of movie hall no %d has %d seats, more than capacity %d", movieHall.MovieHallNo, len(seatMap.Seats), movieHall.Capacity)
+		return fmt.Errorf("Seat map of movie hall no %d has %d seats, more than capacity %d", movieHall.MovieHallNo, len(seatMap.Seats), movieHall.Capacity)
+	}
+
+	// Check whether movie hall already registered or not
+	key, _ := getCompositeKey(ctx, movieHallKeyIndex, movieHall.TheatreId, strconv.Itoa(movieHall.MovieHallNo))
+	if data, err := ctx.GetStub().GetState(key); err != nil {
+		log.Errorf("Failed to get state for movie hall, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for movie hall, Got error: %s", err.Error())
+	} else if data != nil {
+		log.Errorf("Movie hall no %d in theatre %s already registered", movieHall.MovieHallNo, movieHall.TheatreId)
+		return fmt.Errorf("Movie hall no %d in theatre %s already registered", movieHall.MovieHallNo, movieHall.TheatreId)
 	}
 
-	return availableSeats, nil
+	if movieHall.AccessibilityFeatures == nil {
+		movieHall.AccessibilityFeatures = []string{}
+	}
+	movieHall.RecordType = 6
+	movieHallAsBytes, _ := json.Marshal(movieHall)
+	if err := ctx.GetStub().PutState(key, movieHallAsBytes); err != nil {
+		log.Errorf("Failed to register movie hall no %d in theatre %s, Error: %s", movieHall.MovieHallNo, movieHall.TheatreId, err.Error())
+		return fmt.Errorf("Failed to register movie hall no %d in theatre %s, Error: %s", movieHall.MovieHallNo, movieHall.TheatreId, err.Error())
+	}
 
+	log.Infof("Movie hall no %d in theatre %s registered successfully !!", movieHall.MovieHallNo, movieHall.TheatreId)
+	return nil
 }
 
 /**
-	Method to book a seat/ ticket
+	Method to register seat map of a movie hall
 */
-func (s *MovieTicket) Book_ticket(ctx contractapi.TransactionContextInterface, ticketStr string) error {
+func (s *MovieTicket) Register_seat_map(ctx contractapi.TransactionContextInterface, seatMapStr string) error {
 	log := logging.MustGetLogger(name)
-	ticket := new(Ticket)
+	seatMap := new(SeatMap)
+	if err := json.Unmarshal([]byte(seatMapStr), &seatMap); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", seatMapStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", seatMapStr, err.Error())
+	} else if seatMap.TheatreId == "" || len(seatMap.Seats) == 0 {
+		log.Errorf("Invalid json input: %s", seatMapStr)
+		return fmt.Errorf("Invalid json input: %s", seatMapStr)
+	}
 
-	// Validate ticket json
-	if err := json.Unmarshal([]byte(ticketStr), &ticket); err != nil {
-		log.Errorf("Invalid json input: %s, Error: %s", ticketStr, err.Error())
-		return fmt.Errorf("Invalid json input: %s, Error: %s", ticketStr, err.Error())
-	} else if ticket.TheatreId == "" || ticket.ShowId == "" || ticket.ShowDate == "" || ticket.ShowTime == "" || ticket.MovieHallNo < 1 || ticket.NoOfSeats < 1 || ticket.LuckyNo < 1 {
-		log.Errorf("Invalid json input: %s", ticketStr)
-		return fmt.Errorf("Invalid json input: %s", ticketStr)
+	// Check whether provided theatre id and movie hall id is valid or not
+	if theatre, err := getTheatre(ctx, seatMap.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatMap.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatMap.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", seatMap.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", seatMap.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to register seat map in theatre %s, Error: %s", theatre.TheatreId, err.Error())
+		return err
+	} else if seatMap.MovieHallNo < 1 || seatMap.MovieHallNo > theatre.MovieHallNos {
+		log.Errorf("Movie hall no %d in theatre %s does not exist.", seatMap.MovieHallNo, theatre.TheatreId)
+		return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", seatMap.MovieHallNo, theatre.TheatreId)
 	}
 
-	// Check availableSteats should be >= requiredSeats
-	if availableSeats, err := GetSeatAvailability(ctx, ticket.TheatreId, ticket.ShowId, ticket.ShowDate, ticket.ShowTime, ticket.MovieHallNo); err != nil {
-		log.Errorf("Got error: %s", err.Error())
-		return fmt.Errorf("Got error: %s", err.Error())
-	} else if availableSeats < ticket.NoOfSeats {
-		log.Errorf("Seats not available")
-		return fmt.Errorf("SEATS_NOT_AVAILABLE")
+	// Every seat should have a row and seat no and should be listed only once. Rows are letters only so that
+	// seat label of row and seat no is unambiguous e.g. A11 is always row A seat 11
+	seatLabels := make(map[string]bool)
+	for _, seat := range seatMap.Seats {
+		if validateSeatRow(seat.Row) != nil || seat.SeatNo < 1 || validateSeatType(seat.SeatType) != nil {
+			log.Errorf("Invalid seat in seat map: %+v", seat)
+			return fmt.Errorf("Invalid seat in seat map: %+v", seat)
+		} else if seatLabels[getSeatLabel(seat)] {
+			log.Errorf("Seat %s is listed more than once in seat map", getSeatLabel(seat))
+			return fmt.Errorf("Seat %s is listed more than once in seat map", getSeatLabel(seat))
+		}
+		seatLabels[getSeatLabel(seat)] = true
 	}
 
-	// Register ticket
-	ticket.RecordType = 2
-	ticketAsBytes, _ := json.Marshal(ticket)
+	// Seats should fit in capacity of the movie hall, if movie hall is registered
+	if movieHall, err := getMovieHall(ctx, seatMap.TheatreId, seatMap.MovieHallNo); err != nil {
+		log.Errorf("Failed to get state for movie hall, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for movie hall, Got error: %s", err.Error())
+	} else if movieHall != nil && len(seatMap.Seats) > movieHall.Capacity {
+		log.Errorf("Seat map has %d seats, more than capacity %d of movie hall no %d", len(seatMap.Seats), movieHall.Capacity, seatMap.MovieHallNo)
+		return fmt.Errorf("Seat map has %d seats, more than capacity %d of movie hall no %d", len(seatMap.Seats), movieHall.Capacity, seatMap.MovieHallNo)
+	}
 
-	if err := ctx.GetStub().PutState(ticket.TicketId, ticketAsBytes); err != nil {
-		log.Errorf("Failed to register ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
-		return fmt.Errorf("Failed to register ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	// Check whether seat map of the movie hall already registered or not
+	key, _ := getCompositeKey(ctx, seatMapKeyIndex, seatMap.TheatreId, strconv.Itoa(seatMap.MovieHallNo))
+	if data, err := ctx.GetStub().GetState(key); err != nil {
+		log.Errorf("Failed to get state for seat map, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for seat map, Got error: %s", err.Error())
+	} else if data != nil {
+		log.Errorf("Seat map of movie hall no %d in theatre %s already registered", seatMap.MovieHallNo, seatMap.TheatreId)
+		return fmt.Errorf("Seat map of movie hall no %d in theatre %s already registered", seatMap.MovieHallNo, seatMap.TheatreId)
+	}
+
+	seatMap.RecordType = 4
+	seatMapAsBytes, _ := json.Marshal(seatMap)
+	if err := ctx.GetStub().PutState(key, seatMapAsBytes); err != nil {
+		log.Errorf("Failed to register seat map of movie hall no %d in theatre %s, Error: %s", seatMap.MovieHallNo, seatMap.TheatreId, err.Error())
+		return fmt.Errorf("Failed to register seat map of movie hall no %d in theatre %s, Error: %s", seatMap.MovieHallNo, seatMap.TheatreId, err.Error())
 	}
 
+	log.Infof("Seat map of movie hall no %d in theatre %s registered successfully !!", seatMap.MovieHallNo, seatMap.TheatreId)
 	return nil
 }
 
 /**
-	Method to replace water bottle with soda bottle
+	Method to set price list of a theatre, replaces the existing price list
 */
-func (s *MovieTicket) Replace_with_soda_bottle(ctx contractapi.TransactionContextInterface, ticketId string) (bool, error) {
+func (s *MovieTicket) Set_price_list(ctx contractapi.TransactionContextInterface, priceListStr string) error {
 	log := logging.MustGetLogger(name)
+	priceList := new(PriceList)
+	if err := json.Unmarshal([]byte(priceListStr), &priceList); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", priceListStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", priceListStr, err.Error())
+	} else if priceList.TheatreId == "" || len(priceList.PriceBands) == 0 {
+		log.Errorf("Invalid json input: %s", priceListStr)
+		return fmt.Errorf("Invalid json input: %s", priceListStr)
+	}
 
-	ticket := new(Ticket)
+	// Only theatre admins of the owning organisation can set prices
+	theatre, err := getTheatre(ctx, priceList.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", priceList.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", priceList.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", priceList.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", priceList.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to set price list of theatre %s, Error: %s", priceList.TheatreId, err.Error())
+		return err
+	}
 
-	// Check whether ticket id is valid or not, if valid get the ticket
-	if data, err := ctx.GetStub().GetState(ticketId); err != nil {
-		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
-		return false, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
-	} else if data == nil {
-		log.Errorf("Invalid ticket id %s", ticketId)
-		return false, fmt.Errorf("Invalid ticket id %s", ticketId)
-	} else if err = json.Unmarshal([]byte(data), &ticket); err != nil {
-		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
-		return false, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
-	} else if ticket.RecordType != 2 {
-		log.Errorf("Invalid ticket id %s", ticketId)
-		return false, fmt.Errorf("Invalid ticket id %s", ticketId)
-	} else if ticket.LuckyNo%2 != 0 {
-		log.Errorf("Not elegible for soda bottle replacement. Ticket id: %s", ticketId)
-		return false, fmt.Errorf("NOT_ELIGIBLE")
+	for _, priceBand := range priceList.PriceBands {
+		if err := validatePriceBand(priceBand); err != nil {
+			log.Errorf("Invalid price band: %+v, Error: %s", priceBand, err.Error())
+			return fmt.Errorf("%s: %+v", err.Error(), priceBand)
+		} else if priceBand.MovieHallNo > theatre.MovieHallNos {
+			log.Errorf("Movie hall no %d in theatre %s does not exist.", priceBand.MovieHallNo, theatre.TheatreId)
+			return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", priceBand.MovieHallNo, theatre.TheatreId)
+		}
 	}
 
-	// Check soda bottle is not replaced already for this ticket id
-	if data, err := ctx.GetStub().GetState("replace_" + ticketId); err != nil {
-		log.Errorf("Failed to check whether bottle is already replace for ticket or not. Ticket Id: %s, Error: %s", ticketId, err.Error())
-		return false, fmt.Errorf("Failed to check whether bottle is already replace for ticket or not. Ticket Id: %s, Error: %s", ticketId, err.Error())
-	} else if data != nil {
-		log.Errorf("Soda bottle is already replaced for ticket id: %s", ticketId)
-		return false, fmt.Errorf("ALREADY_REPLACED")
+	key, _ := getCompositeKey(ctx, priceListKeyIndex, priceList.TheatreId)
+	priceList.RecordType = 7
+	priceListAsBytes, _ := json.Marshal(priceList)
+	if err := ctx.GetStub().PutState(key, priceListAsBytes); err != nil {
+		log.Errorf("Failed to set price list of theatre with theatre id: %s, Error: %s", priceList.TheatreId, err.Error())
+		return fmt.Errorf("Failed to set price list of theatre with theatre id: %s, Error: %s", priceList.TheatreId, err.Error())
+	}
+
+	log.Infof("Price list of theatre with theatre id: %s set successfully !!", priceList.TheatreId)
+	return nil
+}
+
+/**
+	Method to register a show
+*/
+func (s *MovieTicket) Register_show(ctx contractapi.TransactionContextInterface, showStr string) error {
+	log := logging.MustGetLogger(name)
+	var err error
+	show := new(Show)
+	if err = json.Unmarshal([]byte(showStr), &show); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showStr, err.Error())
+	}
+
+	// Check whether provided theatre id and movie hall id is valid or not
+	var location *time.Location
+	theatre, err := getTheatre(ctx, show.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", show.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", show.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", show.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", show.TheatreId)
+	} else {
+		if err := checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+			log.Errorf("Client is not authorised to register show in theatre %s, Error: %s", theatre.TheatreId, err.Error())
+			return err
+		}
+		if show.MovieHallNo < 1 || show.MovieHallNo > theatre.MovieHallNos {
+			log.Errorf("Movie hall no %d in theatre %s does not exist.", show.MovieHallNo, theatre.TheatreId)
+			return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", show.MovieHallNo, theatre.TheatreId)
+		}
+		if location, err = getTheatreLocation(theatre); err != nil {
+			log.Errorf("Invalid time zone: %s for theatre id: %s", theatre.TimeZone, theatre.TheatreId)
+			return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+		}
+
+		// Show can be registered only in a movie hall with seats and the required screen type
+		if capacity, err := getMovieHallCapacity(ctx, theatre, show.MovieHallNo); err != nil {
+			log.Errorf("Failed to get capacity of movie hall no %d, Got error: %s", show.MovieHallNo, err.Error())
+			return fmt.Errorf("Failed to get capacity of movie hall no %d, Got error: %s", show.MovieHallNo, err.Error())
+		} else if capacity < 1 {
+			log.Errorf("Movie hall no %d in theatre %s has no seats", show.MovieHallNo, theatre.TheatreId)
+			return fmt.Errorf("Movie hall no %d in theatre %s has no seats", show.MovieHallNo, theatre.TheatreId)
+		}
+		if err = checkScreenType(ctx, show, show.MovieHallNo); err != nil {
+			log.Errorf("Movie hall no %d in theatre %s does not have screen type %s", show.MovieHallNo, theatre.TheatreId, show.RequiredScreenType)
+			return fmt.Errorf("%s: movie hall no %d, screen type %s", err.Error(), show.MovieHallNo, show.RequiredScreenType)
+		}
 	}
 
-	// Check cafeteria inventory
-	cafeteria := new(Cafeteria)
-	if data, err := ctx.GetStub().GetState("cafeteria_" + ticket.TheatreId); err != nil {
-		log.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
-		return false, fmt.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
-	} else if err = json.Unmarshal([]byte(data), &cafeteria); err != nil {
-		log.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
-		return false, fmt.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
-	} else if cafeteria.SodaBottleQuantity < 1 {
-		log.Errorf("Soda bottle is out of stock for theatre id: %s", ticket.TheatreId)
-		return false, fmt.Errorf("OUT_OF_STOCK")
+	if err = validateShowTime(show.ShowTime); err != nil {
+		log.Errorf("Invalid show time: %s, show time should be in HH:MM format", show.ShowTime)
+		return fmt.Errorf("INVALID_SHOW_TIME: %s", show.ShowTime)
 	}
 
-	sodaBottleReplacement := new(SodaBottleReplacement)
-	sodaBottleReplacement.TicketId = ticketId
-	sodaBottleReplacementAsBytes, _ := json.Marshal(sodaBottleReplacement)
-	if err := ctx.GetStub().PutState("replace_"+ticketId, sodaBottleReplacementAsBytes); err != nil {
-		log.Errorf("Failed to write soda replacement record for ticket id: %s, Error: %s", ticketId, err.Error())
-		return false, fmt.Errorf("Failed to write soda replacement record for ticket id: %s, Error: %s", ticketId, err.Error())
+	if err = validatePrices(show.Prices); err != nil {
+		log.Errorf("Invalid show prices: %v, Error: %s", show.Prices, err.Error())
+		return fmt.Errorf("%s: %v", err.Error(), show.Prices)
 	}
 
-	cafeteria.SodaBottleQuantity--
-	cafeteriaAsBytes, _ := json.Marshal(cafeteria)
-	if err := ctx.GetStub().PutState("cafeteria_"+ticket.TheatreId, cafeteriaAsBytes); err != nil {
-		log.Errorf("Failed to update cafeteria with theatre id: %s, Error: %s", ticket.TheatreId, err.Error())
-		return false, fmt.Errorf("Failed to update cafeteria with theatre id: %s, Error: %s", ticket.TheatreId, err.Error())
+	// Movie hall is busy for runtime and cleaning buffer of the show
+	if show.RuntimeMinutes < 1 || show.CleaningBufferMinutes < 0 || show.RuntimeMinutes+show.CleaningBufferMinutes >= 24*60 {
+		log.Errorf("Invalid show runtime: %d or cleaning buffer: %d", show.RuntimeMinutes, show.CleaningBufferMinutes)
+		return fmt.Errorf("Invalid show runtime: %d or cleaning buffer: %d", show.RuntimeMinutes, show.CleaningBufferMinutes)
 	}
 
-	return true, nil
+	var showStartDate, showEndDate time.Time
+	if showStartDate, err = parseDate(show.ShowStartDate); err != nil {
+		// Start date parsing issue
+		log.Errorf("Invalid show start date: %s, Error: %s", show.ShowStartDate, err.Error())
+		return fmt.Errorf("INVALID_DATE: show start date %s", show.ShowStartDate)
+	}
+
+	if showEndDate, err = parseDate(show.ShowEndDate); err != nil {
+		// End date parsing issue
+		log.Errorf("Invalid show end date: %s, Error: %s", show.ShowEndDate, err.Error())
+		return fmt.Errorf("INVALID_DATE: show end date %s", show.ShowEndDate)
+	}
+
+	if showEndDate.Before(showStartDate) {
+		// End date < Start date
+		log.Errorf("Invalid show start & end dates. Start date: %s, End date: %s", show.ShowStartDate, show.ShowEndDate)
+		return fmt.Errorf("Invalid show start & end dates. Start date: %s, End date: %s", show.ShowStartDate, show.ShowEndDate)
+	}
+
+	for d := showStartDate; !d.After(showEndDate); d = d.AddDate(0, 0, 1) {
+		// Register show for each day
+		key, _ := getCompositeKey(ctx, showKeyIndex, show.TheatreId, d.Format("2006-01-02"), show.ShowTime, strconv.Itoa(show.MovieHallNo))
+		// Check whether any existing show exist on same date and time, slot of a cancelled show can be reused
+		if existingShow, err := getShow(ctx, key); err != nil {
+			log.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
+			return fmt.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
+		} else if existingShow != nil && existingShow.Status != showStatusCancelled {
+			log.Errorf("Show already exist on date: %s and time %s", d.Format("2006-01-02"), show.ShowTime)
+			return fmt.Errorf("Show already exist on date: %s and time %s", d.Format("2006-01-02"), show.ShowTime)
+		} else {
+			// Check show does not overlap any other show in the movie hall
+			show.ShowDate = d.Format("2006-01-02")
+			if err := checkShowOverlap(ctx, show, location, key); err != nil {
+				log.Errorf("Show on date: %s and time %s can not be registered, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+				return fmt.Errorf("Show on date: %s and time %s can not be registered, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+			}
+
+			// register the show
+			if err := setShowSalesCloseTime(theatre, show); err != nil {
+				log.Errorf("Failed to get sales window of show on date: %s and time %s, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+				return fmt.Errorf("Failed to get sales window of show on date: %s and time %s, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+			}
+			show.Status = showStatusScheduled
+			show.RecordType = 1
+			showAsBytes, _ := json.Marshal(show)
+			if err := ctx.GetStub().PutState(key, showAsBytes); err != nil {
+				log.Errorf("Failed to register show with show id: %s, Error: %s", show.ShowId, err.Error())
+				return fmt.Errorf("Failed to register show with show id: %s, Error: %s", show.ShowId, err.Error())
+			}
+		}
+	}
+
+	showRegisteredEventData := new(ShowRegisteredEventData)
+	showRegisteredEventData.TheatreId = show.TheatreId
+	showRegisteredEventData.MovieHallNo = show.MovieHallNo
+	showRegisteredEventData.ShowId = show.ShowId
+	showRegisteredEventData.ShowName = show.ShowName
+	showRegisteredEventData.ShowStartDate = show.ShowStartDate
+	showRegisteredEventData.ShowEndDate = show.ShowEndDate
+	showRegisteredEventData.ShowTime = show.ShowTime
+	if err = setEvent(ctx, showRegisteredEvent, showRegisteredEventData); err != nil {
+		log.Errorf("Failed to set show registered event for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to set show registered event for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	log.Infof("Show with show id: %s registered successfully !!", show.ShowId)
+	return nil
+}
+
+/**
+	Method to add an item to cafeteria catalogue of a theatre or change name and price of an existing item
+*/
+func (s *MovieTicket) Set_cafeteria_item(ctx contractapi.TransactionContextInterface, cafeteriaItemStr string) error {
+	log := logging.MustGetLogger(name)
+	cafeteriaItem := new(CafeteriaItem)
+	if err := json.Unmarshal([]byte(cafeteriaItemStr), &cafeteriaItem); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", cafeteriaItemStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", cafeteriaItemStr, err.Error())
+	} else if cafeteriaItem.TheatreId == "" || cafeteriaItem.Sku == "" || cafeteriaItem.Name == "" || cafeteriaItem.UnitPrice < 0 || cafeteriaItem.Stock < 0 {
+		log.Errorf("Invalid json input: %s", cafeteriaItemStr)
+		return fmt.Errorf("Invalid json input: %s", cafeteriaItemStr)
+	}
+
+	// Only theatre admins of the owning organisation can change the catalogue
+	if theatre, err := getTheatre(ctx, cafeteriaItem.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", cafeteriaItem.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", cafeteriaItem.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", cafeteriaItem.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", cafeteriaItem.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to change cafeteria catalogue of theatre %s, Error: %s", cafeteriaItem.TheatreId, err.Error())
+		return err
+	}
+
+	// Stock of an existing item is changed only through Add_cafeteria_inventory
+	if existingItem, err := getCafeteriaItem(ctx, cafeteriaItem.TheatreId, cafeteriaItem.Sku); err != nil {
+		log.Errorf("Failed to get state for cafeteria item %s, Got error: %s", cafeteriaItem.Sku, err.Error())
+		return fmt.Errorf("Failed to get state for cafeteria item %s, Got error: %s", cafeteriaItem.Sku, err.Error())
+	} else if existingItem != nil {
+		cafeteriaItem.Stock = existingItem.Stock
+	}
+
+	key, _ := getCompositeKey(ctx, cafeteriaItemKeyIndex, cafeteriaItem.TheatreId, cafeteriaItem.Sku)
+	cafeteriaItem.RecordType = 8
+	cafeteriaItemAsBytes, _ := json.Marshal(cafeteriaItem)
+	if err := ctx.GetStub().PutState(key, cafeteriaItemAsBytes); err != nil {
+		log.Errorf("Failed to set cafeteria item %s of theatre id: %s, Error: %s", cafeteriaItem.Sku, cafeteriaItem.TheatreId, err.Error())
+		return fmt.Errorf("Failed to set cafeteria item %s of theatre id: %s, Error: %s", cafeteriaItem.Sku, cafeteriaItem.TheatreId, err.Error())
+	}
+
+	log.Infof("Cafeteria item %s of theatre id: %s set successfully !!", cafeteriaItem.Sku, cafeteriaItem.TheatreId)
+	return nil
+}
+
+/**
+	Method to get cafeteria catalogue of a theatre
+*/
+func (s *MovieTicket) Get_cafeteria_items(ctx contractapi.TransactionContextInterface, theatreId string) (*CafeteriaCatalogue, error) {
+	log := logging.MustGetLogger(name)
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(cafeteriaItemKeyIndex, []string{theatreId})
+	if err != nil {
+		log.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+	defer resultsIterator.Close()
+
+	cafeteriaCatalogue := new(CafeteriaCatalogue)
+	cafeteriaCatalogue.Items = []CafeteriaItem{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+			return nil, fmt.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+		}
+		cafeteriaItem := new(CafeteriaItem)
+		if err = json.Unmarshal(queryResult.Value, &cafeteriaItem); err != nil {
+			log.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+			return nil, fmt.Errorf("Failed to get cafeteria items of theatre id: %s, Error: %s", theatreId, err.Error())
+		}
+		cafeteriaCatalogue.Items = append(cafeteriaCatalogue.Items, *cafeteriaItem)
+	}
+	return cafeteriaCatalogue, nil
+}
+
+/**
+	Method to add soda bottles to cafeteria's inventory, kept for clients written before the cafeteria catalogue
+*/
+func (s *MovieTicket) Add_cafeteria_inventory(ctx contractapi.TransactionContextInterface, theatreId string, sodaBottleQuantity int) error {
+	return s.Add_cafeteria_item_inventory(ctx, theatreId, sodaBottleSku, sodaBottleQuantity)
+}
+
+/**
+	Method to add stock of a cafeteria item, negative quantity removes stock e.g. damaged or expired items
+*/
+func (s *MovieTicket) Add_cafeteria_item_inventory(ctx contractapi.TransactionContextInterface, theatreId, sku string, quantity int) error {
+	log := logging.MustGetLogger(name)
+	if quantity == 0 {
+		log.Errorf("Invalid quantity: %d", quantity)
+		return fmt.Errorf("Invalid quantity: %d", quantity)
+	}
+
+	// Only theatre admins of the owning organisation can add inventory
+	if theatre, err := getTheatre(ctx, theatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to add inventory to cafeteria of theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	}
+
+	cafeteriaItem, err := updateCafeteriaStock(ctx, theatreId, sku, quantity)
+	if err != nil {
+		log.Errorf("Failed to update stock of cafeteria item %s for theatre id: %s, Error: %s", sku, theatreId, err.Error())
+		return err
+	}
+
+	cafeteriaInventoryAddedEventData := new(CafeteriaInventoryAddedEventData)
+	cafeteriaInventoryAddedEventData.TheatreId = theatreId
+	cafeteriaInventoryAddedEventData.Sku = sku
+	cafeteriaInventoryAddedEventData.QuantityAdded = quantity
+	cafeteriaInventoryAddedEventData.Stock = cafeteriaItem.Stock
+	if err := setEvent(ctx, cafeteriaInventoryAddedEvent, cafeteriaInventoryAddedEventData); err != nil {
+		log.Errorf("Failed to set inventory added event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set inventory added event for theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	log.Infof("Inventry added to cafeteria successfully for theatre id: %s", theatreId)
+	return nil
+}
+
+/**
+	Method to move soda bottle stock stored before the cafeteria catalogue to the soda bottle item of the catalogue
+*/
+func (s *MovieTicket) Migrate_cafeteria_stock(ctx contractapi.TransactionContextInterface, theatreId string) error {
+	log := logging.MustGetLogger(name)
+
+	// Only theatre admins of the owning organisation can migrate stock
+	if theatre, err := getTheatre(ctx, theatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to migrate cafeteria stock of theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	}
+
+	legacyCafeteria := new(LegacyCafeteria)
+	if data, err := ctx.GetStub().GetState(legacyCafeteriaKeyPrefix + theatreId); err != nil {
+		log.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if data == nil {
+		log.Errorf("Cafeteria stock of theatre id %s is already migrated", theatreId)
+		return fmt.Errorf("ALREADY_MIGRATED")
+	} else if err = json.Unmarshal(data, &legacyCafeteria); err != nil {
+		log.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for cafeteria for theatre id: %s, Got error: %s", theatreId, err.Error())
+	}
+
+	// Soda bottle item is added to the catalogue if the theatre has not set it yet
+	cafeteriaItem, err := getCafeteriaItem(ctx, theatreId, sodaBottleSku)
+	if err != nil {
+		log.Errorf("Failed to get state for cafeteria item %s, Got error: %s", sodaBottleSku, err.Error())
+		return fmt.Errorf("Failed to get state for cafeteria item %s, Got error: %s", sodaBottleSku, err.Error())
+	} else if cafeteriaItem == nil {
+		cafeteriaItem = &CafeteriaItem{TheatreId: theatreId, Sku: sodaBottleSku, Name: sodaBottleName, RecordType: 8}
+	}
+	cafeteriaItem.Stock += legacyCafeteria.SodaBottleQuantity
+	key, _ := getCompositeKey(ctx, cafeteriaItemKeyIndex, theatreId, sodaBottleSku)
+	cafeteriaItemAsBytes, _ := json.Marshal(cafeteriaItem)
+	if err := ctx.GetStub().PutState(key, cafeteriaItemAsBytes); err != nil {
+		log.Errorf("Failed to migrate cafeteria stock of theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to migrate cafeteria stock of theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+	if err := ctx.GetStub().DelState(legacyCafeteriaKeyPrefix + theatreId); err != nil {
+		log.Errorf("Failed to delete cafeteria of theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to delete cafeteria of theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	cafeteriaInventoryAddedEventData := new(CafeteriaInventoryAddedEventData)
+	cafeteriaInventoryAddedEventData.TheatreId = theatreId
+	cafeteriaInventoryAddedEventData.Sku = sodaBottleSku
+	cafeteriaInventoryAddedEventData.QuantityAdded = legacyCafeteria.SodaBottleQuantity
+	cafeteriaInventoryAddedEventData.Stock = cafeteriaItem.Stock
+	if err := setEvent(ctx, cafeteriaInventoryAddedEvent, cafeteriaInventoryAddedEventData); err != nil {
+		log.Errorf("Failed to set inventory added event for theatre id: %s, Error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to set inventory added event for theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+
+	log.Infof("Cafeteria stock of theatre id: %s migrated successfully !!", theatreId)
+	return nil
+}
+
+/**
+	Method to get list of shows on sale using rich query
+*/
+func (s *MovieTicket) Get_shows(ctx contractapi.TransactionContextInterface, showSearchQueryStr string) (*ShowSearchResult, error) {
+	log := logging.MustGetLogger(name)
+	showSearchQuery := new(ShowSearchQuery)
+	if err := json.Unmarshal([]byte(showSearchQueryStr), &showSearchQuery); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
+	} else if err = validateShowSearchQuery(showSearchQuery); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSearchQuery.ShowDate, showSearchQuery.ShowTime, err.Error())
+		return nil, err
+	}
+	
+	// Create rich query string, shows whose sales window is closed are hidden
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	queryString := CreateShowSearchQuery(showSearchQuery, txTime.Format(time.RFC3339))
+	log.Infof("Querying chaincode with query string: %s", queryString)
+	
+	// Execute couchdb rich query to get list of all available shows
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		log.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+		return nil, fmt.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+	}
+
+	var shows []Show
+	var queryResult *queryresult.KV
+	for resultsIterator.HasNext() {
+		queryResult, err = resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Got error: %s", err.Error())
+		}
+		var show Show
+		_ = json.Unmarshal(queryResult.Value, &show)
+		shows = append(shows, show)
+	}
+
+	showSearchResult := new(ShowSearchResult)
+	if shows != nil {
+		showSearchResult.ShowList = shows
+	} else {
+		showSearchResult.ShowList = []Show{}
+	}
+
+	return showSearchResult, nil
+
+}
+
+/**
+	Method to get a page of shows on sale using rich query with pagination. A page has pageSize shows unless there are
+	no more shows, fetched records count is the no. of shows returned
+*/
+func (s *MovieTicket) Get_shows_with_pagination(ctx contractapi.TransactionContextInterface, showSearchQueryStr string, pageSize int32, bookmark string) (*PaginatedShowSearchResult, error) {
+	log := logging.MustGetLogger(name)
+	showSearchQuery := new(ShowSearchQuery)
+	if err := json.Unmarshal([]byte(showSearchQueryStr), &showSearchQuery); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSearchQueryStr, err.Error())
+	} else if pageSize < 1 {
+		log.Errorf("Invalid page size: %d", pageSize)
+		return nil, fmt.Errorf("Invalid page size: %d", pageSize)
+	} else if err = validateShowSearchQuery(showSearchQuery); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSearchQuery.ShowDate, showSearchQuery.ShowTime, err.Error())
+		return nil, err
+	}
+
+	// Create rich query string, shows whose sales window is closed are hidden
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	queryString := CreateShowSearchQuery(showSearchQuery, txTime.Format(time.RFC3339))
+	log.Infof("Querying chaincode with query string: %s, page size: %d, bookmark: %s", queryString, pageSize, bookmark)
+
+	// Execute couchdb rich query to get a page of available shows
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		log.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+		return nil, fmt.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+	}
+	defer resultsIterator.Close()
+
+	paginatedShowSearchResult := new(PaginatedShowSearchResult)
+	paginatedShowSearchResult.ShowList = []Show{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Got error: %s", err.Error())
+		}
+		var show Show
+		_ = json.Unmarshal(queryResult.Value, &show)
+		paginatedShowSearchResult.ShowList = append(paginatedShowSearchResult.ShowList, show)
+	}
+	paginatedShowSearchResult.Bookmark = responseMetadata.Bookmark
+	paginatedShowSearchResult.FetchedRecordsCount = responseMetadata.FetchedRecordsCount
+
+	return paginatedShowSearchResult, nil
+}
+
+/**
+	Method to get no. of available seats/ tickets
+*/
+func (s *MovieTicket) Get_seat_availability(ctx contractapi.TransactionContextInterface, seatAvailabilityQueryStr string) (int, error) {
+	seatAvailability, err := s.Get_free_seats(ctx, seatAvailabilityQueryStr)
+	if err != nil {
+		return 0, err
+	}
+	return seatAvailability.AvailableSeats, nil
+}
+
+/**
+	Method to get no. of available seats and free seats of the movie hall's seat map
+*/
+func (s *MovieTicket) Get_free_seats(ctx contractapi.TransactionContextInterface, seatAvailabilityQueryStr string) (*SeatAvailability, error) {
+	log := logging.MustGetLogger(name)
+	query := new(SeatAvailabilityQuery)
+
+	if err := json.Unmarshal([]byte(seatAvailabilityQueryStr), &query); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", seatAvailabilityQueryStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", seatAvailabilityQueryStr, err.Error())
+	} else if query.TheatreId == "" || query.ShowId == "" || query.ShowDate == "" || query.ShowTime == "" || query.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", seatAvailabilityQueryStr)
+		return nil, fmt.Errorf("Invalid json input: %s", seatAvailabilityQueryStr)
+	} else if err = validateShowDateTime(query.ShowDate, query.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", query.ShowDate, query.ShowTime, err.Error())
+		return nil, fmt.Errorf("%s: show date %s, show time %s", err.Error(), query.ShowDate, query.ShowTime)
+	}
+	
+	// Get available seats
+	seatAvailability, err := getSeatAvailability(ctx, query.TheatreId, query.ShowId, query.ShowDate, query.ShowTime, query.MovieHallNo, "")
+	if err != nil {
+		log.Errorf("Got error: %s", err.Error())
+		return nil, fmt.Errorf("Error: %s", err.Error())
+	}
+
+	return seatAvailability, nil
+
+}
+
+/**
+	Method to book a seat/ ticket, returns the ticket id
+*/
+func (s *MovieTicket) Book_ticket(ctx contractapi.TransactionContextInterface, ticketStr string) (string, error) {
+	log := logging.MustGetLogger(name)
+	ticket := new(Ticket)
+
+	// Validate ticket json
+	if err := json.Unmarshal([]byte(ticketStr), &ticket); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", ticketStr, err.Error())
+		return "", fmt.Errorf("Invalid json input: %s, Error: %s", ticketStr, err.Error())
+	} else if ticket.TheatreId == "" || ticket.ShowId == "" || ticket.ShowDate == "" || ticket.ShowTime == "" || ticket.MovieHallNo < 1 || (ticket.NoOfSeats < 1 && len(ticket.Seats) == 0) {
+		log.Errorf("Invalid json input: %s", ticketStr)
+		return "", fmt.Errorf("Invalid json input: %s", ticketStr)
+	} else if err = validateShowDateTime(ticket.ShowDate, ticket.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, err.Error())
+		return "", fmt.Errorf("%s: show date %s, show time %s", err.Error(), ticket.ShowDate, ticket.ShowTime)
+	}
+
+	// Customers can book tickets for themselves, box office can book tickets only for its own theatre
+	theatre, err := getTheatre(ctx, ticket.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		return "", fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		return "", fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+	} else if err = checkBookingClient(ctx, theatre); err != nil {
+		log.Errorf("Client is not authorised to book ticket in theatre %s, Error: %s", ticket.TheatreId, err.Error())
+		return "", err
+	}
+
+	// Tickets can be sold only in sales window of the show
+	if err = checkSalesWindow(ctx, theatre, ticket.ShowDate, ticket.ShowTime); err != nil {
+		log.Errorf("Tickets can not be sold for show on date: %s and time: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, err.Error())
+		return "", err
+	}
+
+	// Ticket id is always generated from transaction id, a client provided id could overwrite another ticket
+	ticket.TicketId = "ticket_" + ctx.GetStub().GetTxID()
+
+	// Ticket is owned by the booking client
+	if ticket.OwnerMspId, err = ctx.GetClientIdentity().GetMSPID(); err != nil {
+		log.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+	}
+	if ticket.OwnerId, err = ctx.GetClientIdentity().GetID(); err != nil {
+		log.Errorf("Failed to get id of client, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get id of client, Error: %s", err.Error())
+	}
+
+	if err = bookTicket(ctx, theatre, ticket, ""); err != nil {
+		return "", err
+	}
+	return ticket.TicketId, nil
+}
+
+/**
+	Function to book seats of a ticket whose show is validated in the given theatre, seats of the given seat hold are booked for the ticket
+*/
+func bookTicket(ctx contractapi.TransactionContextInterface, theatre *Theatre, ticket *Ticket, holdId string) error {
+	log := logging.MustGetLogger(name)
+
+	// Lucky no. is drawn by chaincode after sales close, lucky no. provided by client is ignored
+	ticket.LuckyNo = 0
+	ticket.BookingTxId = ctx.GetStub().GetTxID()
+	ticket.PendingTransfer = nil
+
+	// Show start time is stored in UTC so that tickets of upcoming and past shows can be queried
+	location, err := getTheatreLocation(theatre)
+	if err != nil {
+		log.Errorf("Failed to get time zone of theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get time zone of theatre id: %s, Error: %s", theatre.TheatreId, err.Error())
+	}
+	showStartTime, err := getShowStartTime(ticket.ShowDate, ticket.ShowTime, location)
+	if err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, err.Error())
+		return fmt.Errorf("Invalid show date: %s or show time: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, err.Error())
+	}
+	ticket.ShowStartsAt = showStartTime.UTC().Format(time.RFC3339)
+
+	// Check ticket id does not overwrite any existing record
+	if data, err := ctx.GetStub().GetState(ticket.TicketId); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticket.TicketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticket.TicketId, err.Error())
+	} else if data != nil {
+		log.Errorf("Ticket id %s already exists", ticket.TicketId)
+		return fmt.Errorf("TICKET_ID_ALREADY_EXISTS")
+	}
+
+	// Customer details are passed through transient map so that they are not part of the transaction
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		log.Errorf("Failed to get transient map, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transient map, Error: %s", err.Error())
+	}
+	var customerDetails *CustomerDetails
+	if customerDetailsStr, ok := transientMap[customerDetailsTransientKey]; ok {
+		customerDetails = new(CustomerDetails)
+		if err = json.Unmarshal(customerDetailsStr, &customerDetails); err != nil {
+			log.Errorf("Invalid customer details in transient map, Error: %s", err.Error())
+			return fmt.Errorf("Invalid customer details in transient map, Error: %s", err.Error())
+		} else if customerDetails.Name == "" || (customerDetails.Phone == "" && customerDetails.Email == "") {
+			log.Errorf("Invalid customer details in transient map, name and phone or email are required")
+			return fmt.Errorf("Invalid customer details in transient map, name and phone or email are required")
+		} else if len(customerDetails.Salt) < minCustomerSaltLength {
+			log.Errorf("Salt of customer details should have at least %d characters", minCustomerSaltLength)
+			return fmt.Errorf("INVALID_SALT: salt should have at least %d characters", minCustomerSaltLength)
+		}
+	}
+
+	// Check selected seats are available, seats of the seat hold are available to this ticket
+	var seatTypeCount map[string]int
+	if ticket.NoOfSeats, seatTypeCount, err = checkSeatsAvailable(ctx, ticket.TheatreId, ticket.ShowId, ticket.ShowDate, ticket.ShowTime, ticket.MovieHallNo, ticket.Seats, ticket.NoOfSeats, holdId); err != nil {
+		log.Errorf("Seats are not available for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return err
+	}
+
+	// Price of the ticket is computed from prices of the show
+	showKey, _ := getCompositeKey(ctx, showKeyIndex, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, strconv.Itoa(ticket.MovieHallNo))
+	show, err := getShow(ctx, showKey)
+	if err != nil {
+		log.Errorf("Failed to get state for show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for show, Got error: %s", err.Error())
+	}
+	if ticket.PriceBreakdown, ticket.TotalPrice, err = getTicketPrice(ctx, show, seatTypeCount); err != nil {
+		log.Errorf("Failed to compute price of ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return err
+	}
+
+	// Store customer details in private data collection and only its hash on ticket
+	if customerDetails != nil {
+		customerDetails.TicketId = ticket.TicketId
+		customerDetailsAsBytes, _ := json.Marshal(customerDetails)
+		if err := ctx.GetStub().PutPrivateData(customerDetailsCollection, ticket.TicketId, customerDetailsAsBytes); err != nil {
+			log.Errorf("Failed to store customer details for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to store customer details for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+		customerHash := sha256.Sum256(customerDetailsAsBytes)
+		ticket.CustomerHash = hex.EncodeToString(customerHash[:])
+	}
+
+	// Add booked seats to show's seat counter and remove the seat hold from it, booking no. of the ticket is taken from the counter
+	showSeatCount, err := getShowSeatCount(ctx, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, ticket.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return fmt.Errorf("Failed to get seat counter for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+	if holdId != "" {
+		removeShowSeatHold(showSeatCount, holdId)
+	}
+	showSeatCount.SoldSeats += ticket.NoOfSeats
+	showSeatCount.Bookings++
+	if err = putShowSeatCount(ctx, showSeatCount); err != nil {
+		log.Errorf("Failed to update seat counter for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return fmt.Errorf("Failed to update seat counter for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+	ticket.BookingNo = showSeatCount.Bookings
+
+	// Register ticket
+	ticket.Status = ticketStatusBooked
+	ticket.RecordType = 2
+	ticketAsBytes, _ := json.Marshal(ticket)
+
+	if err := ctx.GetStub().PutState(ticket.TicketId, ticketAsBytes); err != nil {
+		log.Errorf("Failed to register ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return fmt.Errorf("Failed to register ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+
+	// Mark selected seats as sold, this also replaces seats held by the seat hold
+	soldSeat := new(SoldSeat)
+	soldSeat.TicketId = ticket.TicketId
+	soldSeatAsBytes, _ := json.Marshal(soldSeat)
+	for _, seatLabel := range ticket.Seats {
+		key, _ := getCompositeKey(ctx, soldSeatKeyIndex, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, strconv.Itoa(ticket.MovieHallNo), seatLabel)
+		if err := ctx.GetStub().PutState(key, soldSeatAsBytes); err != nil {
+			log.Errorf("Failed to mark seat %s as sold for ticket id: %s, Error: %s", seatLabel, ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to mark seat %s as sold for ticket id: %s, Error: %s", seatLabel, ticket.TicketId, err.Error())
+		}
+	}
+
+	if err := setEvent(ctx, ticketBookedEvent, getTicketEventData(ticket)); err != nil {
+		log.Errorf("Failed to set ticket booked event for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return fmt.Errorf("Failed to set ticket booked event for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+
+	log.Infof("Ticket with ticket id: %s booked successfully !!", ticket.TicketId)
+	return nil
+}
+
+/**
+	Method to hold seats of a show for seatHoldMinutes until payment is confirmed, returns the hold id
+*/
+func (s *MovieTicket) Hold_seats(ctx contractapi.TransactionContextInterface, seatHoldStr string) (string, error) {
+	log := logging.MustGetLogger(name)
+	seatHold := new(SeatHold)
+
+	if err := json.Unmarshal([]byte(seatHoldStr), &seatHold); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", seatHoldStr, err.Error())
+		return "", fmt.Errorf("Invalid json input: %s, Error: %s", seatHoldStr, err.Error())
+	} else if seatHold.TheatreId == "" || seatHold.ShowId == "" || seatHold.ShowDate == "" || seatHold.ShowTime == "" || seatHold.MovieHallNo < 1 || (seatHold.NoOfSeats < 1 && len(seatHold.Seats) == 0) {
+		log.Errorf("Invalid json input: %s", seatHoldStr)
+		return "", fmt.Errorf("Invalid json input: %s", seatHoldStr)
+	} else if err = validateShowDateTime(seatHold.ShowDate, seatHold.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", seatHold.ShowDate, seatHold.ShowTime, err.Error())
+		return "", fmt.Errorf("%s: show date %s, show time %s", err.Error(), seatHold.ShowDate, seatHold.ShowTime)
+	}
+
+	// Seats can be held by clients which can book tickets in the theatre
+	theatre, err := getTheatre(ctx, seatHold.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatHold.TheatreId, err.Error())
+		return "", fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatHold.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", seatHold.TheatreId)
+		return "", fmt.Errorf("Theatre with theatre id %s does not exist", seatHold.TheatreId)
+	} else if err = checkBookingClient(ctx, theatre); err != nil {
+		log.Errorf("Client is not authorised to hold seats in theatre %s, Error: %s", seatHold.TheatreId, err.Error())
+		return "", err
+	}
+
+	// Seats can be held only in sales window of the show
+	if err = checkSalesWindow(ctx, theatre, seatHold.ShowDate, seatHold.ShowTime); err != nil {
+		log.Errorf("Seats can not be held for show on date: %s and time: %s, Error: %s", seatHold.ShowDate, seatHold.ShowTime, err.Error())
+		return "", err
+	}
+
+	if seatHold.NoOfSeats, _, err = checkSeatsAvailable(ctx, seatHold.TheatreId, seatHold.ShowId, seatHold.ShowDate, seatHold.ShowTime, seatHold.MovieHallNo, seatHold.Seats, seatHold.NoOfSeats, ""); err != nil {
+		log.Errorf("Seats are not available for seat hold: %s, Error: %s", seatHoldStr, err.Error())
+		return "", err
+	}
+
+	// Seat hold is owned by the holding client and expires against transaction timestamp
+	if seatHold.OwnerMspId, err = ctx.GetClientIdentity().GetMSPID(); err != nil {
+		log.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+	}
+	if seatHold.OwnerId, err = ctx.GetClientIdentity().GetID(); err != nil {
+		log.Errorf("Failed to get id of client, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get id of client, Error: %s", err.Error())
+	}
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+
+	// A client can hold seats only a few times at once, so that it can not keep seats of shows away from other clients
+	if noOfHolds, err := countActiveSeatHolds(ctx, seatHold.OwnerMspId, seatHold.OwnerId, txTime); err != nil {
+		log.Errorf("Failed to get seat holds of client, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get seat holds of client, Error: %s", err.Error())
+	} else if noOfHolds >= maxActiveSeatHolds {
+		log.Errorf("Client already has %d active seat holds", noOfHolds)
+		return "", fmt.Errorf("TOO_MANY_HOLDS: client can have at most %d active seat holds", maxActiveSeatHolds)
+	}
+
+	seatHold.HoldId = "hold_" + ctx.GetStub().GetTxID()
+	seatHold.ExpiresAt = txTime.Add(seatHoldMinutes * time.Minute).Format(time.RFC3339)
+	seatHold.RecordType = 12
+
+	seatHoldAsBytes, _ := json.Marshal(seatHold)
+	if err := ctx.GetStub().PutState(seatHold.HoldId, seatHoldAsBytes); err != nil {
+		log.Errorf("Failed to register seat hold with hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+		return "", fmt.Errorf("Failed to register seat hold with hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+	}
+	clientHoldKey, _ := getCompositeKey(ctx, clientHoldKeyIndex, seatHold.OwnerMspId, seatHold.OwnerId, seatHold.HoldId)
+	if err := ctx.GetStub().PutState(clientHoldKey, []byte{0x00}); err != nil {
+		log.Errorf("Failed to add seat hold with hold id: %s to seat holds of client, Error: %s", seatHold.HoldId, err.Error())
+		return "", fmt.Errorf("Failed to add seat hold with hold id: %s to seat holds of client, Error: %s", seatHold.HoldId, err.Error())
+	}
+
+	// Mark selected seats as held
+	heldSeat := new(SoldSeat)
+	heldSeat.HoldId = seatHold.HoldId
+	heldSeat.ExpiresAt = seatHold.ExpiresAt
+	heldSeatAsBytes, _ := json.Marshal(heldSeat)
+	for _, seatLabel := range seatHold.Seats {
+		key, _ := getCompositeKey(ctx, soldSeatKeyIndex, seatHold.TheatreId, seatHold.ShowDate, seatHold.ShowTime, strconv.Itoa(seatHold.MovieHallNo), seatLabel)
+		if err := ctx.GetStub().PutState(key, heldSeatAsBytes); err != nil {
+			log.Errorf("Failed to mark seat %s as held for hold id: %s, Error: %s", seatLabel, seatHold.HoldId, err.Error())
+			return "", fmt.Errorf("Failed to mark seat %s as held for hold id: %s, Error: %s", seatLabel, seatHold.HoldId, err.Error())
+		}
+	}
+
+	// Add seat hold to show's seat counter
+	showSeatCount, err := getShowSeatCount(ctx, seatHold.TheatreId, seatHold.ShowDate, seatHold.ShowTime, seatHold.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter for hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+		return "", fmt.Errorf("Failed to get seat counter for hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+	}
+	showSeatCount.Holds = append(showSeatCount.Holds, SeatHoldCount{HoldId: seatHold.HoldId, NoOfSeats: seatHold.NoOfSeats, ExpiresAt: seatHold.ExpiresAt})
+	if err = putShowSeatCount(ctx, showSeatCount); err != nil {
+		log.Errorf("Failed to update seat counter for hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+		return "", fmt.Errorf("Failed to update seat counter for hold id: %s, Error: %s", seatHold.HoldId, err.Error())
+	}
+
+	log.Infof("Seats held successfully with hold id: %s until %s !!", seatHold.HoldId, seatHold.ExpiresAt)
+	return seatHold.HoldId, nil
+}
+
+/**
+	Method to book a ticket for seats of a seat hold which has not expired, returns the ticket id
+*/
+func (s *MovieTicket) Confirm_hold(ctx contractapi.TransactionContextInterface, holdId string) (string, error) {
+	log := logging.MustGetLogger(name)
+
+	seatHold, err := getSeatHold(ctx, holdId)
+	if err != nil {
+		log.Errorf("Failed to get state for hold id: %s, Got error: %s", holdId, err.Error())
+		return "", fmt.Errorf("Failed to get state for hold id: %s, Got error: %s", holdId, err.Error())
+	} else if seatHold == nil {
+		log.Errorf("Seat hold with hold id %s does not exist", holdId)
+		return "", fmt.Errorf("Seat hold with hold id %s does not exist", holdId)
+	}
+
+	// Only the client which held the seats can confirm the seat hold
+	if err = checkClientIdentity(ctx, seatHold.OwnerMspId, seatHold.OwnerId); err != nil {
+		log.Errorf("Client is not authorised to confirm seat hold %s, Error: %s", holdId, err.Error())
+		return "", err
+	}
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return "", fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	} else if isHoldExpired(seatHold.ExpiresAt, txTime) {
+		log.Errorf("Seat hold with hold id %s expired at %s", holdId, seatHold.ExpiresAt)
+		return "", fmt.Errorf("HOLD_EXPIRED")
+	}
+
+	// Tickets can be sold only in sales window of the show
+	theatre, err := getTheatre(ctx, seatHold.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatHold.TheatreId, err.Error())
+		return "", fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", seatHold.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", seatHold.TheatreId)
+		return "", fmt.Errorf("Theatre with theatre id %s does not exist", seatHold.TheatreId)
+	} else if err = checkSalesWindow(ctx, theatre, seatHold.ShowDate, seatHold.ShowTime); err != nil {
+		log.Errorf("Tickets can not be sold for show on date: %s and time: %s, Error: %s", seatHold.ShowDate, seatHold.ShowTime, err.Error())
+		return "", err
+	}
+
+	ticket := new(Ticket)
+	ticket.TicketId = "ticket_" + ctx.GetStub().GetTxID()
+	ticket.TheatreId = seatHold.TheatreId
+	ticket.ShowId = seatHold.ShowId
+	ticket.ShowDate = seatHold.ShowDate
+	ticket.ShowTime = seatHold.ShowTime
+	ticket.MovieHallNo = seatHold.MovieHallNo
+	ticket.NoOfSeats = seatHold.NoOfSeats
+	ticket.Seats = seatHold.Seats
+	ticket.OwnerMspId = seatHold.OwnerMspId
+	ticket.OwnerId = seatHold.OwnerId
+
+	if err = bookTicket(ctx, theatre, ticket, holdId); err != nil {
+		return "", err
+	}
+
+	// Seat hold is done once its seats are booked
+	if err := deleteSeatHold(ctx, holdId); err != nil {
+		log.Errorf("Failed to delete seat hold with hold id: %s, Error: %s", holdId, err.Error())
+		return "", fmt.Errorf("Failed to delete seat hold with hold id: %s, Error: %s", holdId, err.Error())
+	}
+
+	log.Infof("Seat hold with hold id: %s confirmed with ticket id: %s !!", holdId, ticket.TicketId)
+	return ticket.TicketId, nil
+}
+
+/**
+	Method to register a promotion of a theatre
+*/
+func (s *MovieTicket) Register_promotion(ctx contractapi.TransactionContextInterface, promotionStr string) error {
+	log := logging.MustGetLogger(name)
+	promotion := new(Promotion)
+	if err := json.Unmarshal([]byte(promotionStr), &promotion); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", promotionStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", promotionStr, err.Error())
+	} else if err = validatePromotion(promotion); err != nil {
+		log.Errorf("Invalid promotion: %s, Error: %s", promotionStr, err.Error())
+		return err
+	}
+
+	// Only theatre admins of the owning organisation can register a promotion
+	if theatre, err := getTheatre(ctx, promotion.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", promotion.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", promotion.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", promotion.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", promotion.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to register promotion in theatre %s, Error: %s", promotion.TheatreId, err.Error())
+		return err
+	}
+
+	// Reward should be an item of the theatre's cafeteria
+	if cafeteriaItem, err := getCafeteriaItem(ctx, promotion.TheatreId, promotion.Sku); err != nil {
+		log.Errorf("Failed to get state for cafeteria item %s, Got error: %s", promotion.Sku, err.Error())
+		return fmt.Errorf("Failed to get state for cafeteria item %s, Got error: %s", promotion.Sku, err.Error())
+	} else if cafeteriaItem == nil {
+		log.Errorf("Cafeteria item %s does not exist in theatre %s", promotion.Sku, promotion.TheatreId)
+		return fmt.Errorf("INVALID_SKU: %s", promotion.Sku)
+	}
+
+	key, _ := getCompositeKey(ctx, promotionKeyIndex, promotion.TheatreId, promotion.PromotionId)
+	if existingPromotion, err := getPromotion(ctx, key); err != nil {
+		log.Errorf("Failed to get state for promotion %s, Got error: %s", promotion.PromotionId, err.Error())
+		return fmt.Errorf("Failed to get state for promotion %s, Got error: %s", promotion.PromotionId, err.Error())
+	} else if existingPromotion != nil {
+		log.Errorf("Promotion %s already exists in theatre %s", promotion.PromotionId, promotion.TheatreId)
+		return fmt.Errorf("Promotion %s already exists in theatre %s", promotion.PromotionId, promotion.TheatreId)
+	}
+
+	promotion.Redemptions = 0
+	promotion.Active = true
+	promotion.RecordType = 10
+	promotionAsBytes, _ := json.Marshal(promotion)
+	if err := ctx.GetStub().PutState(key, promotionAsBytes); err != nil {
+		log.Errorf("Failed to register promotion %s, Error: %s", promotion.PromotionId, err.Error())
+		return fmt.Errorf("Failed to register promotion %s, Error: %s", promotion.PromotionId, err.Error())
+	}
+
+	log.Infof("Promotion %s of theatre id: %s registered successfully !!", promotion.PromotionId, promotion.TheatreId)
+	return nil
+}
+
+/**
+	Method to start or stop a promotion of a theatre
+*/
+func (s *MovieTicket) Set_promotion_active(ctx contractapi.TransactionContextInterface, theatreId, promotionId string, active bool) error {
+	log := logging.MustGetLogger(name)
+
+	// Only theatre admins of the owning organisation can change a promotion
+	if theatre, err := getTheatre(ctx, theatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to change promotion in theatre %s, Error: %s", theatreId, err.Error())
+		return err
+	}
+
+	key, _ := getCompositeKey(ctx, promotionKeyIndex, theatreId, promotionId)
+	promotion, err := getPromotion(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get state for promotion %s, Got error: %s", promotionId, err.Error())
+		return fmt.Errorf("Failed to get state for promotion %s, Got error: %s", promotionId, err.Error())
+	} else if promotion == nil {
+		log.Errorf("Promotion %s does not exist in theatre %s", promotionId, theatreId)
+		return fmt.Errorf("Promotion %s does not exist in theatre %s", promotionId, theatreId)
+	}
+
+	promotion.Active = active
+	promotionAsBytes, _ := json.Marshal(promotion)
+	if err := ctx.GetStub().PutState(key, promotionAsBytes); err != nil {
+		log.Errorf("Failed to update promotion %s, Error: %s", promotionId, err.Error())
+		return fmt.Errorf("Failed to update promotion %s, Error: %s", promotionId, err.Error())
+	}
+
+	log.Infof("Promotion %s of theatre id: %s updated successfully !!", promotionId, theatreId)
+	return nil
+}
+
+/**
+	Method to get promotions of a theatre
+*/
+func (s *MovieTicket) Get_promotions(ctx contractapi.TransactionContextInterface, theatreId string) (*PromotionList, error) {
+	log := logging.MustGetLogger(name)
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(promotionKeyIndex, []string{theatreId})
+	if err != nil {
+		log.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+	}
+	defer resultsIterator.Close()
+
+	promotionList := new(PromotionList)
+	promotionList.Promotions = []Promotion{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+			return nil, fmt.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+		}
+		promotion := new(Promotion)
+		if err = json.Unmarshal(queryResult.Value, &promotion); err != nil {
+			log.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+			return nil, fmt.Errorf("Failed to get promotions of theatre id: %s, Error: %s", theatreId, err.Error())
+		}
+		promotionList.Promotions = append(promotionList.Promotions, *promotion)
+	}
+	return promotionList, nil
+}
+
+/**
+	Method to replace water bottle with soda bottle
+*/
+func (s *MovieTicket) Replace_with_soda_bottle(ctx contractapi.TransactionContextInterface, ticketId string) (bool, error) {
+	log := logging.MustGetLogger(name)
+	cafeteriaItemRedeemedEventData, err := redeemCafeteriaItem(ctx, ticketId, sodaBottleSku)
+	if err != nil {
+		return false, err
+	}
+
+	// Listeners of soda bottle replacement get the event they subscribed to
+	sodaBottleReplacedEventData := new(SodaBottleReplacedEventData)
+	sodaBottleReplacedEventData.TicketId = ticketId
+	sodaBottleReplacedEventData.TheatreId = cafeteriaItemRedeemedEventData.TheatreId
+	sodaBottleReplacedEventData.SodaBottleQuantity = cafeteriaItemRedeemedEventData.Stock
+	if err := setEvent(ctx, sodaBottleReplacedEvent, sodaBottleReplacedEventData); err != nil {
+		log.Errorf("Failed to set soda bottle replaced event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return false, fmt.Errorf("Failed to set soda bottle replaced event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	return true, nil
+}
+
+/**
+	Method to give a cafeteria item against a ticket, an item can be given only once per ticket
+*/
+func (s *MovieTicket) Redeem_cafeteria_item(ctx contractapi.TransactionContextInterface, ticketId, sku string) (bool, error) {
+	log := logging.MustGetLogger(name)
+	cafeteriaItemRedeemedEventData, err := redeemCafeteriaItem(ctx, ticketId, sku)
+	if err != nil {
+		return false, err
+	}
+
+	if err := setEvent(ctx, cafeteriaItemRedeemedEvent, cafeteriaItemRedeemedEventData); err != nil {
+		log.Errorf("Failed to set cafeteria item redeemed event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return false, fmt.Errorf("Failed to set cafeteria item redeemed event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	return true, nil
+}
+
+/**
+	Function to give a cafeteria item against a ticket and take it out of stock, returns data of the redemption
+*/
+func redeemCafeteriaItem(ctx contractapi.TransactionContextInterface, ticketId, sku string) (*CafeteriaItemRedeemedEventData, error) {
+	log := logging.MustGetLogger(name)
+
+	ticket := new(Ticket)
+
+	// Check whether ticket id is valid or not, if valid get the ticket
+	if data, err := ctx.GetStub().GetState(ticketId); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if data == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if err = json.Unmarshal([]byte(data), &ticket); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket.RecordType != 2 {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if ticket.Status == ticketStatusCancelled {
+		log.Errorf("Ticket id %s is cancelled", ticketId)
+		return nil, fmt.Errorf("TICKET_CANCELLED")
+	} else if ticket.Status == ticketStatusRefundEligible {
+		log.Errorf("Show of ticket id %s is cancelled", ticketId)
+		return nil, fmt.Errorf("TICKET_REFUND_ELIGIBLE")
+	}
+
+	// Only box office of the theatre can give the item
+	if theatre, err := getTheatre(ctx, ticket.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		return nil, fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to redeem cafeteria item in theatre %s, Error: %s", ticket.TheatreId, err.Error())
+		return nil, err
+	}
+
+	// Check item is not redeemed already for this ticket id
+	key, _ := getCompositeKey(ctx, redemptionKeyIndex, ticketId, sku)
+	if data, err := ctx.GetStub().GetState(key); err != nil {
+		log.Errorf("Failed to check whether %s is already redeemed for ticket or not. Ticket Id: %s, Error: %s", sku, ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to check whether %s is already redeemed for ticket or not. Ticket Id: %s, Error: %s", sku, ticketId, err.Error())
+	} else if data != nil {
+		log.Errorf("%s is already redeemed for ticket id: %s", sku, ticketId)
+		return nil, fmt.Errorf("ALREADY_REDEEMED")
+	}
+	if sku == sodaBottleSku {
+		// Soda bottle given before the cafeteria catalogue
+		if data, err := ctx.GetStub().GetState(legacyReplacementKeyPrefix + ticketId); err != nil {
+			log.Errorf("Failed to check whether soda bottle is already replaced for ticket or not. Ticket Id: %s, Error: %s", ticketId, err.Error())
+			return nil, fmt.Errorf("Failed to check whether soda bottle is already replaced for ticket or not. Ticket Id: %s, Error: %s", ticketId, err.Error())
+		} else if data != nil {
+			log.Errorf("Soda bottle is already replaced for ticket id: %s", ticketId)
+			return nil, fmt.Errorf("ALREADY_REDEEMED")
+		}
+	}
+
+	// Ticket should be eligible for a promotion of the theatre rewarding the item, lucky no. is drawn when ticket is read
+	promotion, err := findPromotion(ctx, ticket, sku)
+	if err != nil {
+		log.Errorf("Failed to get promotions of theatre id: %s, Error: %s", ticket.TheatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get promotions of theatre id: %s, Error: %s", ticket.TheatreId, err.Error())
+	} else if promotion == nil {
+		log.Errorf("Not elegible for redemption of %s. Ticket id: %s", sku, ticketId)
+		return nil, fmt.Errorf("NOT_ELIGIBLE")
+	}
+	promotion.Redemptions++
+	promotionKey, _ := getCompositeKey(ctx, promotionKeyIndex, promotion.TheatreId, promotion.PromotionId)
+	promotionAsBytes, _ := json.Marshal(promotion)
+	if err := ctx.GetStub().PutState(promotionKey, promotionAsBytes); err != nil {
+		log.Errorf("Failed to update promotion %s, Error: %s", promotion.PromotionId, err.Error())
+		return nil, fmt.Errorf("Failed to update promotion %s, Error: %s", promotion.PromotionId, err.Error())
+	}
+
+	// Take the item out of cafeteria inventory
+	cafeteriaItem, err := updateCafeteriaStock(ctx, ticket.TheatreId, sku, -1)
+	if err != nil {
+		log.Errorf("Failed to update stock of cafeteria item %s for theatre id: %s, Error: %s", sku, ticket.TheatreId, err.Error())
+		return nil, err
+	}
+
+	redemption := new(Redemption)
+	redemption.TicketId = ticketId
+	redemption.TheatreId = ticket.TheatreId
+	redemption.Sku = sku
+	redemption.PromotionId = promotion.PromotionId
+	redemptionAsBytes, _ := json.Marshal(redemption)
+	if err := ctx.GetStub().PutState(key, redemptionAsBytes); err != nil {
+		log.Errorf("Failed to write redemption record of %s for ticket id: %s, Error: %s", sku, ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to write redemption record of %s for ticket id: %s, Error: %s", sku, ticketId, err.Error())
+	}
+
+	cafeteriaItemRedeemedEventData := new(CafeteriaItemRedeemedEventData)
+	cafeteriaItemRedeemedEventData.TicketId = ticketId
+	cafeteriaItemRedeemedEventData.TheatreId = ticket.TheatreId
+	cafeteriaItemRedeemedEventData.Sku = sku
+	cafeteriaItemRedeemedEventData.PromotionId = promotion.PromotionId
+	cafeteriaItemRedeemedEventData.Stock = cafeteriaItem.Stock
+	return cafeteriaItemRedeemedEventData, nil
+}
+
+/**
+	Method to cancel a ticket and release its seats
+*/
+func (s *MovieTicket) Cancel_ticket(ctx contractapi.TransactionContextInterface, ticketId string) error {
+	log := logging.MustGetLogger(name)
+
+	ticket := new(Ticket)
+
+	// Check whether ticket id is valid or not, if valid get the ticket
+	if data, err := ctx.GetStub().GetState(ticketId); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if data == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if err = json.Unmarshal([]byte(data), &ticket); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket.RecordType != 2 {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if ticket.Status == ticketStatusCancelled {
+		log.Errorf("Ticket id %s is already cancelled", ticketId)
+		return fmt.Errorf("ALREADY_CANCELLED")
+	} else if ticket.Status == ticketStatusRefundEligible {
+		log.Errorf("Show of ticket id %s is cancelled, ticket is already refund eligible", ticketId)
+		return fmt.Errorf("TICKET_REFUND_ELIGIBLE")
+	}
+
+	// Ticket can be cancelled by its owner or box office of the theatre
+	theatre, err := getTheatre(ctx, ticket.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+	} else if checkTicketOwner(ctx, ticket) != nil {
+		if err = checkTheatreOwner(ctx, theatre, roleBoxOffice); err != nil {
+			log.Errorf("Client is not authorised to cancel ticket in theatre %s, Error: %s", ticket.TheatreId, err.Error())
+			return err
+		}
+	}
+	location, err := getTheatreLocation(theatre)
+	if err != nil {
+		log.Errorf("Invalid time zone: %s for theatre id: %s", theatre.TimeZone, theatre.TheatreId)
+		return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+	}
+
+	// Ticket can be cancelled only before the show starts
+	showStartTime, err := getShowStartTime(ticket.ShowDate, ticket.ShowTime, location)
+	if err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s for ticket id: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, ticketId, err.Error())
+		return fmt.Errorf("Invalid show date: %s or show time: %s for ticket id: %s, Error: %s", ticket.ShowDate, ticket.ShowTime, ticketId, err.Error())
+	}
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	} else if !txTime.Before(showStartTime) {
+		log.Errorf("Show for ticket id %s has already started", ticketId)
+		return fmt.Errorf("SHOW_ALREADY_STARTED")
+	}
+
+	// Mark ticket as cancelled, ticket is kept on ledger for audit
+	ticket.Status = ticketStatusCancelled
+	ticketAsBytes, _ := json.Marshal(ticket)
+	if err := ctx.GetStub().PutState(ticketId, ticketAsBytes); err != nil {
+		log.Errorf("Failed to cancel ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to cancel ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	// Release seats of the ticket
+	for _, seatLabel := range ticket.Seats {
+		key, _ := getCompositeKey(ctx, soldSeatKeyIndex, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, strconv.Itoa(ticket.MovieHallNo), seatLabel)
+		soldSeat := new(SoldSeat)
+		if data, err := ctx.GetStub().GetState(key); err != nil {
+			log.Errorf("Failed to get state for seat %s of ticket id: %s, Error: %s", seatLabel, ticketId, err.Error())
+			return fmt.Errorf("Failed to get state for seat %s of ticket id: %s, Error: %s", seatLabel, ticketId, err.Error())
+		} else if data == nil {
+			continue
+		} else if err = json.Unmarshal([]byte(data), &soldSeat); err != nil || soldSeat.TicketId != ticketId {
+			// Seat is not held by this ticket
+			continue
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			log.Errorf("Failed to release seat %s of ticket id: %s, Error: %s", seatLabel, ticketId, err.Error())
+			return fmt.Errorf("Failed to release seat %s of ticket id: %s, Error: %s", seatLabel, ticketId, err.Error())
+		}
+	}
+
+	// Remove released seats from show's seat counter
+	if _, err := updateShowSeatCount(ctx, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, ticket.MovieHallNo, -ticket.NoOfSeats, 0); err != nil {
+		log.Errorf("Failed to update seat counter for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to update seat counter for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	// Undo cafeteria item redemptions and cancel cafeteria orders of this ticket, their items go back to stock
+	restock := make(map[string]int)
+	if err := undoRedemptions(ctx, ticket.TheatreId, ticketId, restock); err != nil {
+		log.Errorf("Failed to undo cafeteria item redemptions for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to undo cafeteria item redemptions for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+	cafeteriaOrders, err := getTicketCafeteriaOrders(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+	ticketEventData := getTicketEventData(ticket)
+	if ticketEventData.CancelledOrderIds, err = cancelCafeteriaOrders(ctx, cafeteriaOrders, restock); err != nil {
+		log.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+	if err := restockCafeteriaItems(ctx, ticket.TheatreId, restock); err != nil {
+		log.Errorf("Failed to return cafeteria items to stock for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to return cafeteria items to stock for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	if err := setEvent(ctx, ticketCancelledEvent, ticketEventData); err != nil {
+		log.Errorf("Failed to set ticket cancelled event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to set ticket cancelled event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	log.Infof("Ticket with ticket id: %s cancelled successfully !!", ticketId)
+	return nil
+}
+
+/**
+	Method to get customer details of a ticket, allowed for the booking client and the theatre's organisation
+*/
+func (s *MovieTicket) Get_customer_details(ctx contractapi.TransactionContextInterface, ticketId string) (*CustomerDetails, error) {
+	log := logging.MustGetLogger(name)
+
+	ticket := new(Ticket)
+
+	// Check whether ticket id is valid or not, if valid get the ticket
+	if data, err := ctx.GetStub().GetState(ticketId); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if data == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if err = json.Unmarshal([]byte(data), &ticket); err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket.RecordType != 2 {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	}
+
+	// Check client is the booking client or belongs to the theatre's organisation
+	if err := checkTicketOwner(ctx, ticket); err != nil {
+		if theatre, err := getTheatre(ctx, ticket.TheatreId); err != nil {
+			log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+			return nil, fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		} else if theatre == nil {
+			log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+			return nil, fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin, roleBoxOffice); err != nil {
+			log.Errorf("Client is not authorised to get customer details of ticket id %s, Error: %s", ticketId, err.Error())
+			return nil, err
+		}
+	}
+
+	customerDetails := new(CustomerDetails)
+	if data, err := ctx.GetStub().GetPrivateData(customerDetailsCollection, ticketId); err != nil {
+		log.Errorf("Failed to get customer details for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get customer details for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if data == nil {
+		log.Errorf("Customer details not found for ticket id %s", ticketId)
+		return nil, fmt.Errorf("CUSTOMER_DETAILS_NOT_FOUND")
+	} else if err = json.Unmarshal([]byte(data), &customerDetails); err != nil {
+		log.Errorf("Failed to get customer details for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get customer details for ticket id: %s, Got error: %s", ticketId, err.Error())
+	}
+
+	return customerDetails, nil
+}
+
+/**
+	Method to update details of a show
+*/
+func (s *MovieTicket) Update_show(ctx contractapi.TransactionContextInterface, showUpdateStr string) error {
+	log := logging.MustGetLogger(name)
+	showUpdate := new(ShowUpdate)
+	if err := json.Unmarshal([]byte(showUpdateStr), &showUpdate); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showUpdateStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showUpdateStr, err.Error())
+	} else if showUpdate.TheatreId == "" || showUpdate.ShowDate == "" || showUpdate.ShowTime == "" || showUpdate.MovieHallNo < 1 || (showUpdate.ShowName == "" && showUpdate.RuntimeMinutes == nil && showUpdate.CleaningBufferMinutes == nil && showUpdate.Prices == nil) {
+		log.Errorf("Invalid json input: %s", showUpdateStr)
+		return fmt.Errorf("Invalid json input: %s", showUpdateStr)
+	}
+
+	if err := validateShowDateTime(showUpdate.ShowDate, showUpdate.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showUpdate.ShowDate, showUpdate.ShowTime, err.Error())
+		return fmt.Errorf("%s: show date %s, show time %s", err.Error(), showUpdate.ShowDate, showUpdate.ShowTime)
+	} else if err = validatePrices(showUpdate.Prices); err != nil {
+		log.Errorf("Invalid show prices: %v, Error: %s", showUpdate.Prices, err.Error())
+		return fmt.Errorf("%s: %v", err.Error(), showUpdate.Prices)
+	}
+
+	// Only theatre admins of the owning organisation can update a show
+	theatre, err := getTheatre(ctx, showUpdate.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showUpdate.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showUpdate.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showUpdate.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", showUpdate.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to update show in theatre %s, Error: %s", showUpdate.TheatreId, err.Error())
+		return err
+	}
+	location, err := getTheatreLocation(theatre)
+	if err != nil {
+		log.Errorf("Invalid time zone: %s for theatre id: %s", theatre.TimeZone, theatre.TheatreId)
+		return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+	}
+
+	key, _ := getCompositeKey(ctx, showKeyIndex, showUpdate.TheatreId, showUpdate.ShowDate, showUpdate.ShowTime, strconv.Itoa(showUpdate.MovieHallNo))
+	show, err := getShow(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get state for show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for show, Got error: %s", err.Error())
+	} else if show == nil {
+		log.Errorf("Show does not exist: %s", showUpdateStr)
+		return fmt.Errorf("INVALID_SHOW_INFO")
+	} else if show.Status == showStatusCancelled {
+		log.Errorf("Show is cancelled: %s", showUpdateStr)
+		return fmt.Errorf("SHOW_CANCELLED")
+	}
+
+	if showUpdate.ShowName != "" {
+		show.ShowName = showUpdate.ShowName
+	}
+	// Prices of tickets already sold are not changed
+	if showUpdate.Prices != nil {
+		show.Prices = showUpdate.Prices
+	}
+	if showUpdate.RuntimeMinutes != nil || showUpdate.CleaningBufferMinutes != nil {
+		if showUpdate.RuntimeMinutes != nil {
+			show.RuntimeMinutes = *showUpdate.RuntimeMinutes
+		}
+		if showUpdate.CleaningBufferMinutes != nil {
+			show.CleaningBufferMinutes = *showUpdate.CleaningBufferMinutes
+		}
+		if show.RuntimeMinutes < 1 || show.CleaningBufferMinutes < 0 || show.RuntimeMinutes+show.CleaningBufferMinutes >= 24*60 {
+			log.Errorf("Invalid show runtime: %d or cleaning buffer: %d", show.RuntimeMinutes, show.CleaningBufferMinutes)
+			return fmt.Errorf("Invalid show runtime: %d or cleaning buffer: %d", show.RuntimeMinutes, show.CleaningBufferMinutes)
+		}
+
+		// Longer show should not run into the next show in the movie hall
+		if err := checkShowOverlap(ctx, show, location, key); err != nil {
+			log.Errorf("Show with show id: %s can not be updated, Error: %s", show.ShowId, err.Error())
+			return fmt.Errorf("Show with show id: %s can not be updated, Error: %s", show.ShowId, err.Error())
+		}
+	}
+
+	showAsBytes, _ := json.Marshal(show)
+	if err := ctx.GetStub().PutState(key, showAsBytes); err != nil {
+		log.Errorf("Failed to update show with show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to update show with show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	showEventData := getShowEventData(show)
+	if err := setEvent(ctx, showUpdatedEvent, showEventData); err != nil {
+		log.Errorf("Failed to set show updated event for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to set show updated event for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	log.Infof("Show with show id: %s updated successfully !!", show.ShowId)
+	return nil
+}
+
+/**
+	Method to reschedule a show to a new date, time or movie hall
+*/
+func (s *MovieTicket) Reschedule_show(ctx contractapi.TransactionContextInterface, showRescheduleStr string) error {
+	log := logging.MustGetLogger(name)
+	showReschedule := new(ShowReschedule)
+	if err := json.Unmarshal([]byte(showRescheduleStr), &showReschedule); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showRescheduleStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showRescheduleStr, err.Error())
+	} else if showReschedule.TheatreId == "" || showReschedule.ShowDate == "" || showReschedule.ShowTime == "" || showReschedule.MovieHallNo < 1 || showReschedule.NewMovieHallNo < 0 {
+		log.Errorf("Invalid json input: %s", showRescheduleStr)
+		return fmt.Errorf("Invalid json input: %s", showRescheduleStr)
+	}
+
+	// Values which are not provided are not changed
+	if showReschedule.NewShowDate == "" {
+		showReschedule.NewShowDate = showReschedule.ShowDate
+	}
+	if showReschedule.NewShowTime == "" {
+		showReschedule.NewShowTime = showReschedule.ShowTime
+	}
+	if showReschedule.NewMovieHallNo == 0 {
+		showReschedule.NewMovieHallNo = showReschedule.MovieHallNo
+	}
+	if showReschedule.NewShowDate == showReschedule.ShowDate && showReschedule.NewShowTime == showReschedule.ShowTime && showReschedule.NewMovieHallNo == showReschedule.MovieHallNo {
+		log.Errorf("New show date, time or movie hall is not provided: %s", showRescheduleStr)
+		return fmt.Errorf("New show date, time or movie hall is not provided: %s", showRescheduleStr)
+	}
+	if err := validateShowDateTime(showReschedule.ShowDate, showReschedule.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showReschedule.ShowDate, showReschedule.ShowTime, err.Error())
+		return fmt.Errorf("%s: show date %s, show time %s", err.Error(), showReschedule.ShowDate, showReschedule.ShowTime)
+	} else if err = validateShowDateTime(showReschedule.NewShowDate, showReschedule.NewShowTime); err != nil {
+		log.Errorf("Invalid new show date: %s or show time: %s, Error: %s", showReschedule.NewShowDate, showReschedule.NewShowTime, err.Error())
+		return fmt.Errorf("%s: new show date %s, new show time %s", err.Error(), showReschedule.NewShowDate, showReschedule.NewShowTime)
+	}
+
+	// Only theatre admins of the owning organisation can reschedule a show
+	theatre, err := getTheatre(ctx, showReschedule.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showReschedule.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showReschedule.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showReschedule.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", showReschedule.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to reschedule show in theatre %s, Error: %s", showReschedule.TheatreId, err.Error())
+		return err
+	} else if showReschedule.NewMovieHallNo > theatre.MovieHallNos {
+		log.Errorf("Movie hall no %d in theatre %s does not exist.", showReschedule.NewMovieHallNo, theatre.TheatreId)
+		return fmt.Errorf("Movie hall no %d in theatre %s does not exist.", showReschedule.NewMovieHallNo, theatre.TheatreId)
+	}
+	location, err := getTheatreLocation(theatre)
+	if err != nil {
+		log.Errorf("Invalid time zone: %s for theatre id: %s", theatre.TimeZone, theatre.TheatreId)
+		return fmt.Errorf("INVALID_TIME_ZONE: %s", theatre.TimeZone)
+	}
+
+	key, _ := getCompositeKey(ctx, showKeyIndex, showReschedule.TheatreId, showReschedule.ShowDate, showReschedule.ShowTime, strconv.Itoa(showReschedule.MovieHallNo))
+	show, err := getShow(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get state for show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for show, Got error: %s", err.Error())
+	} else if show == nil {
+		log.Errorf("Show does not exist: %s", showRescheduleStr)
+		return fmt.Errorf("INVALID_SHOW_INFO")
+	} else if show.Status == showStatusCancelled {
+		log.Errorf("Show is cancelled: %s", showRescheduleStr)
+		return fmt.Errorf("SHOW_CANCELLED")
+	}
+
+	// Show can be rescheduled only before it starts and only to a time in future
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	if showStartTime, err := getShowStartTime(show.ShowDate, show.ShowTime, location); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+		return fmt.Errorf("Invalid show date: %s or show time: %s, Error: %s", show.ShowDate, show.ShowTime, err.Error())
+	} else if !txTime.Before(showStartTime) {
+		log.Errorf("Show with show id %s has already started", show.ShowId)
+		return fmt.Errorf("SHOW_ALREADY_STARTED")
+	}
+	newShowStartTime, err := getShowStartTime(showReschedule.NewShowDate, showReschedule.NewShowTime, location)
+	if err != nil {
+		log.Errorf("Invalid new show date: %s or show time: %s, Error: %s", showReschedule.NewShowDate, showReschedule.NewShowTime, err.Error())
+		return fmt.Errorf("Invalid new show date: %s or show time: %s, Error: %s", showReschedule.NewShowDate, showReschedule.NewShowTime, err.Error())
+	} else if !txTime.Before(newShowStartTime) {
+		log.Errorf("New show date: %s and time: %s is in past", showReschedule.NewShowDate, showReschedule.NewShowTime)
+		return fmt.Errorf("Invalid new show date: %s and time: %s, it is in past", showReschedule.NewShowDate, showReschedule.NewShowTime)
+	}
+
+	// Check target slot is free, slot of a cancelled show can be reused
+	newKey, _ := getCompositeKey(ctx, showKeyIndex, showReschedule.TheatreId, showReschedule.NewShowDate, showReschedule.NewShowTime, strconv.Itoa(showReschedule.NewMovieHallNo))
+	if existingShow, err := getShow(ctx, newKey); err != nil {
+		log.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for existing show, Got error: %s", err.Error())
+	} else if existingShow != nil && existingShow.Status != showStatusCancelled {
+		log.Errorf("Show already exist on date: %s and time %s", showReschedule.NewShowDate, showReschedule.NewShowTime)
+		return fmt.Errorf("SHOW_SLOT_NOT_FREE")
+	}
+	rescheduledShow := *show
+	rescheduledShow.ShowDate = showReschedule.NewShowDate
+	rescheduledShow.ShowTime = showReschedule.NewShowTime
+	rescheduledShow.MovieHallNo = showReschedule.NewMovieHallNo
+	if err := checkShowOverlap(ctx, &rescheduledShow, location, key); err != nil {
+		log.Errorf("Show with show id: %s can not be rescheduled, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("SHOW_SLOT_NOT_FREE: %s", err.Error())
+	}
+
+	tickets, err := getShowTickets(ctx, show.TheatreId, show.ShowDate, show.ShowTime, show.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get tickets of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get tickets of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+	newSeatMap, err := getSeatMap(ctx, showReschedule.TheatreId, showReschedule.NewMovieHallNo)
+	if err != nil {
+		log.Errorf("Got error: %s", err.Error())
+		return fmt.Errorf("Got error: %s", err.Error())
+	}
+	newCapacity, err := getMovieHallCapacity(ctx, theatre, showReschedule.NewMovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get capacity of movie hall no %d, Got error: %s", showReschedule.NewMovieHallNo, err.Error())
+		return fmt.Errorf("Failed to get capacity of movie hall no %d, Got error: %s", showReschedule.NewMovieHallNo, err.Error())
+	} else if err = checkScreenType(ctx, show, showReschedule.NewMovieHallNo); err != nil {
+		log.Errorf("Movie hall no %d in theatre %s does not have screen type %s", showReschedule.NewMovieHallNo, theatre.TheatreId, show.RequiredScreenType)
+		return fmt.Errorf("%s: movie hall no %d, screen type %s", err.Error(), showReschedule.NewMovieHallNo, show.RequiredScreenType)
+	}
+
+	// Seat counter of the new slot is read before tickets are moved into the slot. Moved tickets keep their booking
+	// no., later bookings continue after the last booking no. of the show
+	showSeatCount, err := getShowSeatCount(ctx, show.TheatreId, show.ShowDate, show.ShowTime, show.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get seat counter for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+	newShowSeatCount, err := getShowSeatCount(ctx, showReschedule.TheatreId, showReschedule.NewShowDate, showReschedule.NewShowTime, showReschedule.NewMovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter for rescheduled show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get seat counter for rescheduled show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	// Seats of the show are sold again for rescheduled show, seat holds of the show are released
+	if err := releaseShowSeats(ctx, show.TheatreId, show.ShowDate, show.ShowTime, show.MovieHallNo); err != nil {
+		log.Errorf("Failed to release seats of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to release seats of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	showEventData := getShowEventData(show)
+	showEventData.PreviousShowDate = show.ShowDate
+	showEventData.PreviousShowTime = show.ShowTime
+	showEventData.PreviousMovieHallNo = show.MovieHallNo
+
+	// Cafeteria orders move with their tickets, orders of refund eligible tickets are cancelled
+	movedSeats := 0
+	restock := make(map[string]int)
+	for _, ticket := range tickets {
+		if ticket.Status == ticketStatusCancelled || ticket.Status == ticketStatusRefundEligible {
+			continue
+		}
+		cafeteriaOrders, err := getTicketCafeteriaOrders(ctx, ticket.TicketId)
+		if err != nil {
+			log.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+
+		// Ticket is moved only if its seats exist in the new movie hall and the movie hall is not full
+		if showReschedule.MoveTickets && ticketFitsSeatMap(ticket, newSeatMap) && movedSeats+ticket.NoOfSeats <= newCapacity {
+			ticket.ShowDate = showReschedule.NewShowDate
+			ticket.ShowTime = showReschedule.NewShowTime
+			ticket.MovieHallNo = showReschedule.NewMovieHallNo
+			ticket.ShowStartsAt = newShowStartTime.UTC().Format(time.RFC3339)
+			showEventData.MovedTicketIds = append(showEventData.MovedTicketIds, ticket.TicketId)
+			movedSeats += ticket.NoOfSeats
+
+			soldSeat := new(SoldSeat)
+			soldSeat.TicketId = ticket.TicketId
+			soldSeatAsBytes, _ := json.Marshal(soldSeat)
+			for _, seatLabel := range ticket.Seats {
+				seatKey, _ := getCompositeKey(ctx, soldSeatKeyIndex, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, strconv.Itoa(ticket.MovieHallNo), seatLabel)
+				if err := ctx.GetStub().PutState(seatKey, soldSeatAsBytes); err != nil {
+					log.Errorf("Failed to mark seat %s as sold for ticket id: %s, Error: %s", seatLabel, ticket.TicketId, err.Error())
+					return fmt.Errorf("Failed to mark seat %s as sold for ticket id: %s, Error: %s", seatLabel, ticket.TicketId, err.Error())
+				}
+			}
+			if err := moveCafeteriaOrders(ctx, cafeteriaOrders, ticket); err != nil {
+				log.Errorf("Failed to move cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+				return fmt.Errorf("Failed to move cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			}
+		} else {
+			ticket.Status = ticketStatusRefundEligible
+			showEventData.RefundEligibleTicketIds = append(showEventData.RefundEligibleTicketIds, ticket.TicketId)
+			cancelledOrderIds, err := cancelCafeteriaOrders(ctx, cafeteriaOrders, restock)
+			if err != nil {
+				log.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+				return fmt.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			}
+			showEventData.CancelledOrderIds = append(showEventData.CancelledOrderIds, cancelledOrderIds...)
+		}
+
+		ticketAsBytes, _ := json.Marshal(ticket)
+		if err := ctx.GetStub().PutState(ticket.TicketId, ticketAsBytes); err != nil {
+			log.Errorf("Failed to update ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to update ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+	}
+
+	if err := restockCafeteriaItems(ctx, show.TheatreId, restock); err != nil {
+		log.Errorf("Failed to return cafeteria items to stock for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to return cafeteria items to stock for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	newShowSeatCount.SoldSeats += movedSeats
+	if newShowSeatCount.Bookings < showSeatCount.Bookings {
+		newShowSeatCount.Bookings = showSeatCount.Bookings
+	}
+	if err := putShowSeatCount(ctx, newShowSeatCount); err != nil {
+		log.Errorf("Failed to update seat counter for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to update seat counter for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	// Lucky draw of the show moves with the show
+	if luckyDraw, err := getLuckyDraw(ctx, showReschedule.TheatreId, showEventData.PreviousShowDate, showEventData.PreviousShowTime, showEventData.PreviousMovieHallNo); err != nil {
+		log.Errorf("Failed to get lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+	} else if luckyDraw != nil {
+		luckyDrawKey, _ := getCompositeKey(ctx, luckyDrawKeyIndex, luckyDraw.TheatreId, luckyDraw.ShowDate, luckyDraw.ShowTime, strconv.Itoa(luckyDraw.MovieHallNo))
+		if err := ctx.GetStub().DelState(luckyDrawKey); err != nil {
+			log.Errorf("Failed to delete lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+			return fmt.Errorf("Failed to delete lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+		}
+		luckyDraw.ShowDate = showReschedule.NewShowDate
+		luckyDraw.ShowTime = showReschedule.NewShowTime
+		luckyDraw.MovieHallNo = showReschedule.NewMovieHallNo
+		luckyDrawKey, _ = getCompositeKey(ctx, luckyDrawKeyIndex, luckyDraw.TheatreId, luckyDraw.ShowDate, luckyDraw.ShowTime, strconv.Itoa(luckyDraw.MovieHallNo))
+		luckyDrawAsBytes, _ := json.Marshal(luckyDraw)
+		if err := ctx.GetStub().PutState(luckyDrawKey, luckyDrawAsBytes); err != nil {
+			log.Errorf("Failed to move lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+			return fmt.Errorf("Failed to move lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+		}
+	}
+
+	// Move the show to its new slot
+	if err := ctx.GetStub().DelState(key); err != nil {
+		log.Errorf("Failed to delete show with show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to delete show with show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+	show.ShowDate = showReschedule.NewShowDate
+	show.ShowTime = showReschedule.NewShowTime
+	show.MovieHallNo = showReschedule.NewMovieHallNo
+	show.Status = showStatusScheduled
+	if err := setShowSalesCloseTime(theatre, show); err != nil {
+		log.Errorf("Failed to get sales window of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get sales window of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+	showAsBytes, _ := json.Marshal(show)
+	if err := ctx.GetStub().PutState(newKey, showAsBytes); err != nil {
+		log.Errorf("Failed to reschedule show with show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to reschedule show with show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	showEventData.ShowDate = show.ShowDate
+	showEventData.ShowTime = show.ShowTime
+	showEventData.MovieHallNo = show.MovieHallNo
+	if err := setEvent(ctx, showRescheduledEvent, showEventData); err != nil {
+		log.Errorf("Failed to set show rescheduled event for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to set show rescheduled event for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	log.Infof("Show with show id: %s rescheduled successfully !!", show.ShowId)
+	return nil
+}
+
+/**
+	Method to cancel a show, sold tickets become refund eligible
+*/
+func (s *MovieTicket) Cancel_show(ctx contractapi.TransactionContextInterface, showSlotStr string) error {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.ShowDate == "" || showSlot.ShowTime == "" || showSlot.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+
+	// Only theatre admins of the owning organisation can cancel a show
+	if theatre, err := getTheatre(ctx, showSlot.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to cancel show in theatre %s, Error: %s", showSlot.TheatreId, err.Error())
+		return err
+	}
+
+	key, _ := getCompositeKey(ctx, showKeyIndex, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, strconv.Itoa(showSlot.MovieHallNo))
+	show, err := getShow(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get state for show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for show, Got error: %s", err.Error())
+	} else if show == nil {
+		log.Errorf("Show does not exist: %s", showSlotStr)
+		return fmt.Errorf("INVALID_SHOW_INFO")
+	} else if show.Status == showStatusCancelled {
+		log.Errorf("Show is already cancelled: %s", showSlotStr)
+		return fmt.Errorf("ALREADY_CANCELLED")
+	}
+
+	// Mark sold tickets refund eligible and cancel their cafeteria orders
+	tickets, err := getShowTickets(ctx, show.TheatreId, show.ShowDate, show.ShowTime, show.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get tickets of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to get tickets of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+	showEventData := getShowEventData(show)
+	restock := make(map[string]int)
+	for _, ticket := range tickets {
+		if ticket.Status == ticketStatusCancelled || ticket.Status == ticketStatusRefundEligible {
+			continue
+		}
+		ticket.Status = ticketStatusRefundEligible
+		ticketAsBytes, _ := json.Marshal(ticket)
+		if err := ctx.GetStub().PutState(ticket.TicketId, ticketAsBytes); err != nil {
+			log.Errorf("Failed to update ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to update ticket with ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+		showEventData.RefundEligibleTicketIds = append(showEventData.RefundEligibleTicketIds, ticket.TicketId)
+
+		cafeteriaOrders, err := getTicketCafeteriaOrders(ctx, ticket.TicketId)
+		if err != nil {
+			log.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to get cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+		cancelledOrderIds, err := cancelCafeteriaOrders(ctx, cafeteriaOrders, restock)
+		if err != nil {
+			log.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return fmt.Errorf("Failed to cancel cafeteria orders for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+		showEventData.CancelledOrderIds = append(showEventData.CancelledOrderIds, cancelledOrderIds...)
+	}
+	if err := restockCafeteriaItems(ctx, show.TheatreId, restock); err != nil {
+		log.Errorf("Failed to return cafeteria items to stock for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to return cafeteria items to stock for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	if err := releaseShowSeats(ctx, show.TheatreId, show.ShowDate, show.ShowTime, show.MovieHallNo); err != nil {
+		log.Errorf("Failed to release seats of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to release seats of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	// Lucky draw of the slot should not be used by a new show in the slot
+	luckyDrawKey, _ := getCompositeKey(ctx, luckyDrawKeyIndex, show.TheatreId, show.ShowDate, show.ShowTime, strconv.Itoa(show.MovieHallNo))
+	if err := ctx.GetStub().DelState(luckyDrawKey); err != nil {
+		log.Errorf("Failed to delete lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to delete lucky draw of show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	// Cancelled show is kept on ledger, its slot can be used by another show
+	show.Status = showStatusCancelled
+	showAsBytes, _ := json.Marshal(show)
+	if err := ctx.GetStub().PutState(key, showAsBytes); err != nil {
+		log.Errorf("Failed to cancel show with show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to cancel show with show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	if err := setEvent(ctx, showCancelledEvent, showEventData); err != nil {
+		log.Errorf("Failed to set show cancelled event for show id: %s, Error: %s", show.ShowId, err.Error())
+		return fmt.Errorf("Failed to set show cancelled event for show id: %s, Error: %s", show.ShowId, err.Error())
+	}
+
+	log.Infof("Show with show id: %s cancelled successfully !!", show.ShowId)
+	return nil
+}
+
+/**
+	Method to commit seed of the lucky draw of a show, only tickets booked after the commitment take part in the draw
+*/
+func (s *MovieTicket) Commit_lucky_draw_seed(ctx contractapi.TransactionContextInterface, showSlotStr, seedCommitment string) error {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+	if commitment, err := hex.DecodeString(seedCommitment); err != nil || len(commitment) != sha256.Size {
+		log.Errorf("Invalid seed commitment: %s", seedCommitment)
+		return fmt.Errorf("INVALID_SEED_COMMITMENT")
+	}
+
+	// Only theatre admins of the owning organisation can commit the seed
+	if theatre, err := getTheatre(ctx, showSlot.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to commit lucky draw seed in theatre %s, Error: %s", showSlot.TheatreId, err.Error())
+		return err
+	}
+
+	key, _ := getCompositeKey(ctx, showKeyIndex, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, strconv.Itoa(showSlot.MovieHallNo))
+	if show, err := getShow(ctx, key); err != nil {
+		log.Errorf("Failed to get state for show, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get state for show, Got error: %s", err.Error())
+	} else if show == nil {
+		log.Errorf("Show does not exist: %s", showSlotStr)
+		return fmt.Errorf("INVALID_SHOW_INFO")
+	} else if show.Status == showStatusCancelled {
+		log.Errorf("Show is cancelled: %s", showSlotStr)
+		return fmt.Errorf("SHOW_CANCELLED")
+	}
+
+	// Seed can be committed only once
+	if luckyDraw, err := getLuckyDraw(ctx, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo); err != nil {
+		log.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+	} else if luckyDraw != nil {
+		log.Errorf("Lucky draw seed already committed: %s", showSlotStr)
+		return fmt.Errorf("SEED_ALREADY_COMMITTED")
+	}
+
+	// Tickets already booked are left out of the draw, seat counter is read so that a concurrent booking invalidates
+	// this transaction
+	showSeatCount, err := getShowSeatCount(ctx, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get seat counter, Got error: %s", err.Error())
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+	luckyDraw := new(LuckyDraw)
+	luckyDraw.TheatreId = showSlot.TheatreId
+	luckyDraw.ShowDate = showSlot.ShowDate
+	luckyDraw.ShowTime = showSlot.ShowTime
+	luckyDraw.MovieHallNo = showSlot.MovieHallNo
+	luckyDraw.SeedCommitment = seedCommitment
+	luckyDraw.CommittedAt = txTime.Format(time.RFC3339)
+	luckyDraw.FromBookingNo = showSeatCount.Bookings + 1
+	luckyDraw.RecordType = 11
+	luckyDrawKey, _ := getCompositeKey(ctx, luckyDrawKeyIndex, luckyDraw.TheatreId, luckyDraw.ShowDate, luckyDraw.ShowTime, strconv.Itoa(luckyDraw.MovieHallNo))
+	luckyDrawAsBytes, _ := json.Marshal(luckyDraw)
+	if err := ctx.GetStub().PutState(luckyDrawKey, luckyDrawAsBytes); err != nil {
+		log.Errorf("Failed to commit lucky draw seed, Error: %s", err.Error())
+		return fmt.Errorf("Failed to commit lucky draw seed, Error: %s", err.Error())
+	}
+
+	if err := setEvent(ctx, luckyDrawCommittedEvent, luckyDraw); err != nil {
+		log.Errorf("Failed to set lucky draw committed event, Error: %s", err.Error())
+		return fmt.Errorf("Failed to set lucky draw committed event, Error: %s", err.Error())
+	}
+
+	log.Infof("Lucky draw seed committed successfully for show: %s", showSlotStr)
+	return nil
+}
+
+/**
+	Method to reveal seed of the lucky draw of a show after its sales close
+*/
+func (s *MovieTicket) Reveal_lucky_draw_seed(ctx contractapi.TransactionContextInterface, showSlotStr, seed string) error {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.MovieHallNo < 1 || seed == "" {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+
+	// Only theatre admins of the owning organisation can reveal the seed
+	theatre, err := getTheatre(ctx, showSlot.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin); err != nil {
+		log.Errorf("Client is not authorised to reveal lucky draw seed in theatre %s, Error: %s", showSlot.TheatreId, err.Error())
+		return err
+	}
+
+	luckyDraw, err := getLuckyDraw(ctx, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+		return fmt.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+	} else if luckyDraw == nil {
+		log.Errorf("Lucky draw seed is not committed: %s", showSlotStr)
+		return fmt.Errorf("SEED_NOT_COMMITTED")
+	} else if luckyDraw.Seed != "" {
+		log.Errorf("Lucky draw seed already revealed: %s", showSlotStr)
+		return fmt.Errorf("SEED_ALREADY_REVEALED")
+	} else if getSeedCommitment(seed) != luckyDraw.SeedCommitment {
+		log.Errorf("Seed does not match commitment of lucky draw: %s", showSlotStr)
+		return fmt.Errorf("SEED_MISMATCH")
+	}
+
+	// Seed is revealed only after tickets can no longer be booked, otherwise bookings could be timed to win
+	_, salesCloseTime, err := getSalesWindow(theatre, showSlot.ShowDate, showSlot.ShowTime)
+	if err != nil {
+		log.Errorf("Failed to get sales window, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get sales window, Error: %s", err.Error())
+	}
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	} else if txTime.Before(salesCloseTime) {
+		log.Errorf("Sales are not closed for show: %s", showSlotStr)
+		return fmt.Errorf("SALES_NOT_CLOSED")
+	}
+
+	luckyDraw.Seed = seed
+	luckyDraw.RevealedAt = txTime.Format(time.RFC3339)
+	luckyDrawKey, _ := getCompositeKey(ctx, luckyDrawKeyIndex, luckyDraw.TheatreId, luckyDraw.ShowDate, luckyDraw.ShowTime, strconv.Itoa(luckyDraw.MovieHallNo))
+	luckyDrawAsBytes, _ := json.Marshal(luckyDraw)
+	if err := ctx.GetStub().PutState(luckyDrawKey, luckyDrawAsBytes); err != nil {
+		log.Errorf("Failed to reveal lucky draw seed, Error: %s", err.Error())
+		return fmt.Errorf("Failed to reveal lucky draw seed, Error: %s", err.Error())
+	}
+
+	if err := setEvent(ctx, luckyDrawRevealedEvent, luckyDraw); err != nil {
+		log.Errorf("Failed to set lucky draw revealed event, Error: %s", err.Error())
+		return fmt.Errorf("Failed to set lucky draw revealed event, Error: %s", err.Error())
+	}
+
+	log.Infof("Lucky draw seed revealed successfully for show: %s", showSlotStr)
+	return nil
+}
+
+/**
+	Method to get lucky draw of a show, used to audit lucky no. of tickets
+*/
+func (s *MovieTicket) Get_lucky_draw(ctx contractapi.TransactionContextInterface, showSlotStr string) (*LuckyDraw, error) {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	}
+
+	luckyDraw, err := getLuckyDraw(ctx, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get lucky draw, Got error: %s", err.Error())
+	} else if luckyDraw == nil {
+		log.Errorf("Lucky draw seed is not committed: %s", showSlotStr)
+		return nil, fmt.Errorf("SEED_NOT_COMMITTED")
+	}
+	return luckyDraw, nil
+}
+
+/**
+	Method to place a cafeteria order for a ticket, stock of every item is taken in the same transaction
+*/
+func (s *MovieTicket) Place_cafeteria_order(ctx contractapi.TransactionContextInterface, cafeteriaOrderStr string) (string, error) {
+	log := logging.MustGetLogger(name)
+	cafeteriaOrder := new(CafeteriaOrder)
+	if err := json.Unmarshal([]byte(cafeteriaOrderStr), &cafeteriaOrder); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", cafeteriaOrderStr, err.Error())
+		return "", fmt.Errorf("Invalid json input: %s, Error: %s", cafeteriaOrderStr, err.Error())
+	} else if cafeteriaOrder.TicketId == "" || len(cafeteriaOrder.Items) == 0 {
+		log.Errorf("Invalid json input: %s", cafeteriaOrderStr)
+		return "", fmt.Errorf("Invalid json input: %s", cafeteriaOrderStr)
+	}
+
+	ticket, err := getTicket(ctx, cafeteriaOrder.TicketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", cafeteriaOrder.TicketId, err.Error())
+		return "", fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", cafeteriaOrder.TicketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", cafeteriaOrder.TicketId)
+		return "", fmt.Errorf("Invalid ticket id %s", cafeteriaOrder.TicketId)
+	} else if ticket.Status != ticketStatusBooked {
+		log.Errorf("Ticket id %s is %s", cafeteriaOrder.TicketId, ticket.Status)
+		return "", fmt.Errorf("TICKET_%s", ticket.Status)
+	}
+
+	// Order can be placed by the booking client or box office of the theatre
+	if err := checkTicketOwner(ctx, ticket); err != nil {
+		if theatre, err := getTheatre(ctx, ticket.TheatreId); err != nil {
+			log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+			return "", fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		} else if theatre == nil {
+			log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+			return "", fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		} else if err = checkTheatreOwner(ctx, theatre, roleBoxOffice); err != nil {
+			log.Errorf("Client is not authorised to place cafeteria order for ticket id %s, Error: %s", ticket.TicketId, err.Error())
+			return "", err
+		}
+	}
+
+	// Take stock of every item, a sku can be listed only once as stock written in this transaction can not be read back
+	skus := make(map[string]bool)
+	cafeteriaOrder.TotalPrice = 0
+	for i := range cafeteriaOrder.Items {
+		orderItem := &cafeteriaOrder.Items[i]
+		if orderItem.Sku == "" || orderItem.Quantity < 1 {
+			log.Errorf("Invalid order item: %+v", *orderItem)
+			return "", fmt.Errorf("Invalid order item: %+v", *orderItem)
+		} else if skus[orderItem.Sku] {
+			log.Errorf("Sku %s is listed more than once in order", orderItem.Sku)
+			return "", fmt.Errorf("DUPLICATE_SKU: %s", orderItem.Sku)
+		}
+		skus[orderItem.Sku] = true
+
+		cafeteriaItem, err := updateCafeteriaStock(ctx, ticket.TheatreId, orderItem.Sku, -orderItem.Quantity)
+		if err != nil {
+			log.Errorf("Failed to update stock of cafeteria item %s for theatre id: %s, Error: %s", orderItem.Sku, ticket.TheatreId, err.Error())
+			return "", err
+		}
+		orderItem.UnitPrice = cafeteriaItem.UnitPrice
+		orderItem.Amount = cafeteriaItem.UnitPrice * int64(orderItem.Quantity)
+		cafeteriaOrder.TotalPrice += orderItem.Amount
+	}
+
+	// Order is delivered to the seats of the ticket
+	cafeteriaOrder.OrderId = "order_" + ctx.GetStub().GetTxID()
+	cafeteriaOrder.TheatreId = ticket.TheatreId
+	cafeteriaOrder.ShowDate = ticket.ShowDate
+	cafeteriaOrder.ShowTime = ticket.ShowTime
+	cafeteriaOrder.MovieHallNo = ticket.MovieHallNo
+	cafeteriaOrder.Seats = ticket.Seats
+	cafeteriaOrder.Status = orderStatusPlaced
+	cafeteriaOrder.RecordType = 9
+	cafeteriaOrderAsBytes, _ := json.Marshal(cafeteriaOrder)
+	if err := ctx.GetStub().PutState(cafeteriaOrder.OrderId, cafeteriaOrderAsBytes); err != nil {
+		log.Errorf("Failed to place cafeteria order for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return "", fmt.Errorf("Failed to place cafeteria order for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+
+	// Orders of a ticket are found through this key when the ticket or its show is cancelled
+	ticketOrderKey, _ := getCompositeKey(ctx, ticketOrderKeyIndex, ticket.TicketId, cafeteriaOrder.OrderId)
+	if err := ctx.GetStub().PutState(ticketOrderKey, []byte{0x00}); err != nil {
+		log.Errorf("Failed to link cafeteria order to ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		return "", fmt.Errorf("Failed to link cafeteria order to ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+	}
+
+	if err := setEvent(ctx, cafeteriaOrderPlacedEvent, getCafeteriaOrderEventData(cafeteriaOrder)); err != nil {
+		log.Errorf("Failed to set cafeteria order placed event for order id: %s, Error: %s", cafeteriaOrder.OrderId, err.Error())
+		return "", fmt.Errorf("Failed to set cafeteria order placed event for order id: %s, Error: %s", cafeteriaOrder.OrderId, err.Error())
+	}
+
+	log.Infof("Cafeteria order with order id: %s placed successfully !!", cafeteriaOrder.OrderId)
+	return cafeteriaOrder.OrderId, nil
+}
+
+/**
+	Method to move a cafeteria order to the next status i.e. placed to prepared and prepared to delivered
+*/
+func (s *MovieTicket) Update_cafeteria_order_status(ctx contractapi.TransactionContextInterface, orderId, status string) error {
+	log := logging.MustGetLogger(name)
+
+	cafeteriaOrder := new(CafeteriaOrder)
+	if data, err := ctx.GetStub().GetState(orderId); err != nil {
+		log.Errorf("Failed to get state for order id: %s, Got error: %s", orderId, err.Error())
+		return fmt.Errorf("Failed to get state for order id: %s, Got error: %s", orderId, err.Error())
+	} else if data == nil {
+		log.Errorf("Invalid order id %s", orderId)
+		return fmt.Errorf("Invalid order id %s", orderId)
+	} else if err = json.Unmarshal(data, &cafeteriaOrder); err != nil || cafeteriaOrder.RecordType != 9 {
+		log.Errorf("Invalid order id %s", orderId)
+		return fmt.Errorf("Invalid order id %s", orderId)
+	}
+
+	// Only theatre staff can prepare and deliver orders
+	if theatre, err := getTheatre(ctx, cafeteriaOrder.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", cafeteriaOrder.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", cafeteriaOrder.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", cafeteriaOrder.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", cafeteriaOrder.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to update cafeteria order %s, Error: %s", orderId, err.Error())
+		return err
+	}
+
+	if !(cafeteriaOrder.Status == orderStatusPlaced && status == orderStatusPrepared) && !(cafeteriaOrder.Status == orderStatusPrepared && status == orderStatusDelivered) {
+		log.Errorf("Order id %s can not be moved from %s to %s", orderId, cafeteriaOrder.Status, status)
+		return fmt.Errorf("INVALID_ORDER_STATUS: %s to %s", cafeteriaOrder.Status, status)
+	}
+
+	cafeteriaOrder.Status = status
+	cafeteriaOrderAsBytes, _ := json.Marshal(cafeteriaOrder)
+	if err := ctx.GetStub().PutState(orderId, cafeteriaOrderAsBytes); err != nil {
+		log.Errorf("Failed to update cafeteria order with order id: %s, Error: %s", orderId, err.Error())
+		return fmt.Errorf("Failed to update cafeteria order with order id: %s, Error: %s", orderId, err.Error())
+	}
+
+	if err := setEvent(ctx, cafeteriaOrderUpdatedEvent, getCafeteriaOrderEventData(cafeteriaOrder)); err != nil {
+		log.Errorf("Failed to set cafeteria order updated event for order id: %s, Error: %s", orderId, err.Error())
+		return fmt.Errorf("Failed to set cafeteria order updated event for order id: %s, Error: %s", orderId, err.Error())
+	}
+
+	log.Infof("Cafeteria order with order id: %s moved to %s successfully !!", orderId, status)
+	return nil
+}
+
+/**
+	Method to get cafeteria orders of a show which are not delivered yet
+*/
+func (s *MovieTicket) Get_open_cafeteria_orders(ctx contractapi.TransactionContextInterface, showSlotStr string) (*CafeteriaOrderList, error) {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return nil, fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return nil, fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+
+	// Only theatre staff can see orders of a show
+	if theatre, err := getTheatre(ctx, showSlot.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+		return nil, fmt.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to get cafeteria orders of theatre %s, Error: %s", showSlot.TheatreId, err.Error())
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(CreateShowOpenOrdersQuery(showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo))
+	if err != nil {
+		log.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	cafeteriaOrderList := new(CafeteriaOrderList)
+	cafeteriaOrderList.Orders = []CafeteriaOrder{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+			return nil, fmt.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+		}
+		cafeteriaOrder := new(CafeteriaOrder)
+		if err = json.Unmarshal(queryResult.Value, &cafeteriaOrder); err != nil {
+			log.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+			return nil, fmt.Errorf("Failed to get cafeteria orders, Error: %s", err.Error())
+		}
+		cafeteriaOrderList.Orders = append(cafeteriaOrderList.Orders, *cafeteriaOrder)
+	}
+	return cafeteriaOrderList, nil
+}
+
+/**
+	Method to get a ticket, allowed for the ticket owner and the theatre's organisation
+*/
+func (s *MovieTicket) Get_ticket(ctx contractapi.TransactionContextInterface, ticketId string) (*Ticket, error) {
+	log := logging.MustGetLogger(name)
+	ticket, err := getTicket(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	}
+
+	// Check client is the ticket owner or belongs to the theatre's organisation
+	if err := checkTicketOwnerOrTheatre(ctx, ticket, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to get ticket id %s, Error: %s", ticketId, err.Error())
+		return nil, err
+	}
+	return ticket, nil
+}
+
+/**
+	Method to get a page of tickets owned by the invoking client, filter is UPCOMING for booked tickets of shows which
+	have not started, PAST for tickets of shows which have started or empty for all tickets
+*/
+func (s *MovieTicket) Get_my_tickets(ctx contractapi.TransactionContextInterface, filter string, pageSize int32, bookmark string) (*PaginatedTicketList, error) {
+	log := logging.MustGetLogger(name)
+	if pageSize < 1 {
+		log.Errorf("Invalid page size: %d", pageSize)
+		return nil, fmt.Errorf("Invalid page size: %d", pageSize)
+	} else if filter != "" && filter != ticketFilterUpcoming && filter != ticketFilterPast {
+		log.Errorf("Invalid filter: %s", filter)
+		return nil, fmt.Errorf("INVALID_FILTER: %s", filter)
+	}
+
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		log.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get MSP id of client, Error: %s", err.Error())
+	}
+	clientId, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		log.Errorf("Failed to get id of client, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get id of client, Error: %s", err.Error())
+	}
+
+	// Tickets are filtered by show start time stored on them in UTC, so show dates in theatre's time zone are compared correctly
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return nil, fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+
+	queryString := CreateOwnerTicketsQuery(mspId, clientId, filter, txTime.Format(time.RFC3339))
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		log.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+		return nil, fmt.Errorf("Error while running rich query: %s, error: %s", queryString, err.Error())
+	}
+	defer resultsIterator.Close()
+
+	paginatedTicketList := new(PaginatedTicketList)
+	paginatedTicketList.Tickets = []Ticket{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Got error: %s", err.Error())
+		}
+		var ticket Ticket
+		if err = json.Unmarshal(queryResult.Value, &ticket); err != nil {
+			return nil, fmt.Errorf("Got error: %s", err.Error())
+		} else if err = completeTicket(ctx, &ticket); err != nil {
+			log.Errorf("Failed to get lucky no. for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+			return nil, fmt.Errorf("Failed to get lucky no. for ticket id: %s, Error: %s", ticket.TicketId, err.Error())
+		}
+		paginatedTicketList.Tickets = append(paginatedTicketList.Tickets, ticket)
+	}
+
+	paginatedTicketList.Bookmark = responseMetadata.Bookmark
+	paginatedTicketList.FetchedRecordsCount = responseMetadata.FetchedRecordsCount
+
+	return paginatedTicketList, nil
+}
+
+/**
+	Method to get every version of a ticket, allowed for the ticket owner and the theatre's organisation
+*/
+func (s *MovieTicket) Get_ticket_history(ctx contractapi.TransactionContextInterface, ticketId string) (*TicketHistory, error) {
+	log := logging.MustGetLogger(name)
+	ticket, err := getTicket(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return nil, fmt.Errorf("Invalid ticket id %s", ticketId)
+	}
+
+	// Check client is the ticket owner or belongs to the theatre's organisation
+	if err := checkTicketOwnerOrTheatre(ctx, ticket, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to get history of ticket id %s, Error: %s", ticketId, err.Error())
+		return nil, err
+	}
+
+	history, err := getKeyHistory(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get history of ticket id: %s, Error: %s", ticketId, err.Error())
+		return nil, fmt.Errorf("Failed to get history of ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	ticketHistory := new(TicketHistory)
+	ticketHistory.TicketId = ticketId
+	ticketHistory.History = []TicketHistoryEntry{}
+	for _, keyModification := range history {
+		entry := TicketHistoryEntry{TxId: keyModification.TxId, Timestamp: getHistoryTimestamp(keyModification), IsDelete: keyModification.IsDelete}
+		if !keyModification.IsDelete {
+			entry.Value = new(Ticket)
+			if err := json.Unmarshal(keyModification.Value, entry.Value); err != nil {
+				log.Errorf("Invalid version of ticket id: %s in transaction %s, Error: %s", ticketId, keyModification.TxId, err.Error())
+				return nil, fmt.Errorf("Invalid version of ticket id: %s in transaction %s, Error: %s", ticketId, keyModification.TxId, err.Error())
+			}
+			// Lucky no. depends only on the booking, every version of the booking shows the drawn lucky no.
+			if entry.Value.BookingTxId == ticket.BookingTxId {
+				entry.Value.LuckyNo = ticket.LuckyNo
+			}
+		}
+		ticketHistory.History = append(ticketHistory.History, entry)
+	}
+	return ticketHistory, nil
+}
+
+/**
+	Method to get every version of a show in a slot
+*/
+func (s *MovieTicket) Get_show_history(ctx contractapi.TransactionContextInterface, showSlotStr string) (*ShowHistory, error) {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return nil, fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return nil, fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return nil, fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+
+	key, _ := getCompositeKey(ctx, showKeyIndex, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, strconv.Itoa(showSlot.MovieHallNo))
+	history, err := getKeyHistory(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get history of show: %s, Error: %s", showSlotStr, err.Error())
+		return nil, fmt.Errorf("Failed to get history of show: %s, Error: %s", showSlotStr, err.Error())
+	}
+
+	showHistory := new(ShowHistory)
+	showHistory.TheatreId = showSlot.TheatreId
+	showHistory.ShowDate = showSlot.ShowDate
+	showHistory.ShowTime = showSlot.ShowTime
+	showHistory.MovieHallNo = showSlot.MovieHallNo
+	showHistory.History = []ShowHistoryEntry{}
+	for _, keyModification := range history {
+		entry := ShowHistoryEntry{TxId: keyModification.TxId, Timestamp: getHistoryTimestamp(keyModification), IsDelete: keyModification.IsDelete}
+		if !keyModification.IsDelete {
+			entry.Value = new(Show)
+			if err := json.Unmarshal(keyModification.Value, entry.Value); err != nil {
+				log.Errorf("Invalid version of show: %s in transaction %s, Error: %s", showSlotStr, keyModification.TxId, err.Error())
+				return nil, fmt.Errorf("Invalid version of show: %s in transaction %s, Error: %s", showSlotStr, keyModification.TxId, err.Error())
+			}
+		}
+		showHistory.History = append(showHistory.History, entry)
+	}
+	return showHistory, nil
+}
+
+/**
+	Method to get every version of a cafeteria item, allowed for the theatre's organisation
+*/
+func (s *MovieTicket) Get_cafeteria_history(ctx contractapi.TransactionContextInterface, theatreId, sku string) (*CafeteriaItemHistory, error) {
+	log := logging.MustGetLogger(name)
+
+	// Only theatre staff can see stock movements of the cafeteria
+	if theatre, err := getTheatre(ctx, theatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", theatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", theatreId)
+		return nil, fmt.Errorf("Theatre with theatre id %s does not exist", theatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to get cafeteria history of theatre %s, Error: %s", theatreId, err.Error())
+		return nil, err
+	}
+
+	key, _ := getCompositeKey(ctx, cafeteriaItemKeyIndex, theatreId, sku)
+	history, err := getKeyHistory(ctx, key)
+	if err != nil {
+		log.Errorf("Failed to get history of cafeteria item %s of theatre id: %s, Error: %s", sku, theatreId, err.Error())
+		return nil, fmt.Errorf("Failed to get history of cafeteria item %s of theatre id: %s, Error: %s", sku, theatreId, err.Error())
+	}
+
+	cafeteriaItemHistory := new(CafeteriaItemHistory)
+	cafeteriaItemHistory.TheatreId = theatreId
+	cafeteriaItemHistory.Sku = sku
+	cafeteriaItemHistory.History = []CafeteriaItemHistoryEntry{}
+	for _, keyModification := range history {
+		entry := CafeteriaItemHistoryEntry{TxId: keyModification.TxId, Timestamp: getHistoryTimestamp(keyModification), IsDelete: keyModification.IsDelete}
+		if !keyModification.IsDelete {
+			entry.Value = new(CafeteriaItem)
+			if err := json.Unmarshal(keyModification.Value, entry.Value); err != nil {
+				log.Errorf("Invalid version of cafeteria item %s in transaction %s, Error: %s", sku, keyModification.TxId, err.Error())
+				return nil, fmt.Errorf("Invalid version of cafeteria item %s in transaction %s, Error: %s", sku, keyModification.TxId, err.Error())
+			}
+		}
+		cafeteriaItemHistory.History = append(cafeteriaItemHistory.History, entry)
+	}
+	return cafeteriaItemHistory, nil
+}
+
+/**
+	Method to release expired seat holds of a show, returns no. of seat holds released. Expired seat holds are also
+	released whenever seat counter of the show is written, this releases them when no more seats are booked or held
+*/
+func (s *MovieTicket) Sweep_expired_holds(ctx contractapi.TransactionContextInterface, showSlotStr string) (int, error) {
+	log := logging.MustGetLogger(name)
+	showSlot := new(ShowSlot)
+	if err := json.Unmarshal([]byte(showSlotStr), &showSlot); err != nil {
+		log.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+		return 0, fmt.Errorf("Invalid json input: %s, Error: %s", showSlotStr, err.Error())
+	} else if showSlot.TheatreId == "" || showSlot.ShowDate == "" || showSlot.ShowTime == "" || showSlot.MovieHallNo < 1 {
+		log.Errorf("Invalid json input: %s", showSlotStr)
+		return 0, fmt.Errorf("Invalid json input: %s", showSlotStr)
+	} else if err = validateShowDateTime(showSlot.ShowDate, showSlot.ShowTime); err != nil {
+		log.Errorf("Invalid show date: %s or show time: %s, Error: %s", showSlot.ShowDate, showSlot.ShowTime, err.Error())
+		return 0, fmt.Errorf("%s: show date %s, show time %s", err.Error(), showSlot.ShowDate, showSlot.ShowTime)
+	}
+
+	// Only theatre staff of the owning organisation can release seat holds
+	if theatre, err := getTheatre(ctx, showSlot.TheatreId); err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+		return 0, fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", showSlot.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+		return 0, fmt.Errorf("Theatre with theatre id %s does not exist", showSlot.TheatreId)
+	} else if err = checkTheatreOwner(ctx, theatre, roleTheatreAdmin, roleBoxOffice); err != nil {
+		log.Errorf("Client is not authorised to release seat holds in theatre %s, Error: %s", showSlot.TheatreId, err.Error())
+		return 0, err
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		return 0, fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+	}
+
+	// Remove expired seat holds from show's seat counter and delete their records
+	showSeatCount, err := getShowSeatCount(ctx, showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, showSlot.MovieHallNo)
+	if err != nil {
+		log.Errorf("Failed to get seat counter for show: %s, Error: %s", showSlotStr, err.Error())
+		return 0, fmt.Errorf("Failed to get seat counter for show: %s, Error: %s", showSlotStr, err.Error())
+	}
+	noOfHoldsReleased, err := pruneExpiredSeatHolds(ctx, showSeatCount)
+	if err != nil {
+		log.Errorf("Failed to release expired seat holds of show: %s, Error: %s", showSlotStr, err.Error())
+		return 0, fmt.Errorf("Failed to release expired seat holds of show: %s, Error: %s", showSlotStr, err.Error())
+	} else if noOfHoldsReleased > 0 {
+		if err = putShowSeatCount(ctx, showSeatCount); err != nil {
+			log.Errorf("Failed to update seat counter for show: %s, Error: %s", showSlotStr, err.Error())
+			return 0, fmt.Errorf("Failed to update seat counter for show: %s, Error: %s", showSlotStr, err.Error())
+		}
+	}
+
+	// Release seats held by expired seat holds
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(soldSeatKeyIndex, []string{showSlot.TheatreId, showSlot.ShowDate, showSlot.ShowTime, strconv.Itoa(showSlot.MovieHallNo)})
+	if err != nil {
+		log.Errorf("Failed to get seats of show: %s, Error: %s", showSlotStr, err.Error())
+		return 0, fmt.Errorf("Failed to get seats of show: %s, Error: %s", showSlotStr, err.Error())
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			log.Errorf("Failed to get seats of show: %s, Error: %s", showSlotStr, err.Error())
+			return 0, fmt.Errorf("Failed to get seats of show: %s, Error: %s", showSlotStr, err.Error())
+		}
+		soldSeat := new(SoldSeat)
+		if err = json.Unmarshal(queryResult.Value, &soldSeat); err != nil || soldSeat.TicketId != "" || soldSeat.HoldId == "" || !isHoldExpired(soldSeat.ExpiresAt, txTime) {
+			// Seat is sold or held by a seat hold which has not expired
+			continue
+		}
+		if err = ctx.GetStub().DelState(queryResult.Key); err != nil {
+			log.Errorf("Failed to release seat held by hold id: %s, Error: %s", soldSeat.HoldId, err.Error())
+			return 0, fmt.Errorf("Failed to release seat held by hold id: %s, Error: %s", soldSeat.HoldId, err.Error())
+		}
+	}
+
+	log.Infof("%d expired seat holds released for show: %s !!", noOfHoldsReleased, showSlotStr)
+	return noOfHoldsReleased, nil
+}
+
+/**
+	Method to transfer a ticket to another client. If the theatre requires acceptance the transfer stays pending
+	until the receiving client accepts it, otherwise the ticket is transferred right away
+*/
+func (s *MovieTicket) Transfer_ticket(ctx contractapi.TransactionContextInterface, ticketId, newOwnerMspId, newOwnerId string) error {
+	log := logging.MustGetLogger(name)
+	if newOwnerMspId == "" || newOwnerId == "" {
+		log.Errorf("Invalid new owner MSP id: %s or owner id: %s", newOwnerMspId, newOwnerId)
+		return fmt.Errorf("Invalid new owner MSP id: %s or owner id: %s", newOwnerMspId, newOwnerId)
+	}
+
+	ticket, err := getTicket(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return fmt.Errorf("Invalid ticket id %s", ticketId)
+	}
+
+	// Only the current owner of the ticket can transfer it
+	if err = checkTicketOwner(ctx, ticket); err != nil {
+		log.Errorf("Client is not authorised to transfer ticket id %s, Error: %s", ticketId, err.Error())
+		return err
+	} else if ticket.OwnerMspId == newOwnerMspId && ticket.OwnerId == newOwnerId {
+		log.Errorf("Ticket id %s is already owned by the new owner", ticketId)
+		return fmt.Errorf("INVALID_NEW_OWNER")
+	}
+
+	theatre, err := getTheatre(ctx, ticket.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+	} else if err = checkTicketTransferRules(ctx, theatre, ticket); err != nil {
+		log.Errorf("Ticket id %s can not be transferred, Error: %s", ticketId, err.Error())
+		return err
+	}
+
+	// Transfer waits for the receiving client, a new transfer replaces any pending transfer
+	if theatre.TransferRules.RequireAcceptance {
+		txTime, err := getTxTime(ctx)
+		if err != nil {
+			log.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+			return fmt.Errorf("Failed to get transaction timestamp, Error: %s", err.Error())
+		}
+		ticket.PendingTransfer = &TicketTransfer{ToMspId: newOwnerMspId, ToId: newOwnerId, RequestedAt: txTime.Format(time.RFC3339)}
+		ticketAsBytes, _ := json.Marshal(ticket)
+		if err := ctx.GetStub().PutState(ticketId, ticketAsBytes); err != nil {
+			log.Errorf("Failed to request transfer of ticket id: %s, Error: %s", ticketId, err.Error())
+			return fmt.Errorf("Failed to request transfer of ticket id: %s, Error: %s", ticketId, err.Error())
+		}
+
+		if err := setEvent(ctx, ticketTransferRequestedEvent, getTicketTransferEventData(ticket, ticket.OwnerMspId, newOwnerMspId)); err != nil {
+			log.Errorf("Failed to set ticket transfer requested event for ticket id: %s, Error: %s", ticketId, err.Error())
+			return fmt.Errorf("Failed to set ticket transfer requested event for ticket id: %s, Error: %s", ticketId, err.Error())
+		}
+
+		log.Infof("Transfer of ticket id: %s to %s requested successfully !!", ticketId, newOwnerMspId)
+		return nil
+	}
+
+	previousOwnerMspId := ticket.OwnerMspId
+	if err := changeTicketOwner(ctx, ticket, newOwnerMspId, newOwnerId); err != nil {
+		log.Errorf("Failed to transfer ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to transfer ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	if err := setEvent(ctx, ticketTransferredEvent, getTicketTransferEventData(ticket, previousOwnerMspId, newOwnerMspId)); err != nil {
+		log.Errorf("Failed to set ticket transferred event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to set ticket transferred event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	log.Infof("Ticket with ticket id: %s transferred from %s to %s successfully !!", ticketId, previousOwnerMspId, newOwnerMspId)
+	return nil
+}
+
+/**
+	Method to accept a pending transfer of a ticket, allowed only for the receiving client
+*/
+func (s *MovieTicket) Accept_ticket_transfer(ctx contractapi.TransactionContextInterface, ticketId string) error {
+	log := logging.MustGetLogger(name)
+
+	ticket, err := getTicket(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if ticket.PendingTransfer == nil {
+		log.Errorf("Ticket id %s does not have a pending transfer", ticketId)
+		return fmt.Errorf("NO_PENDING_TRANSFER")
+	}
+
+	if err = checkClientIdentity(ctx, ticket.PendingTransfer.ToMspId, ticket.PendingTransfer.ToId); err != nil {
+		log.Errorf("Client is not authorised to accept transfer of ticket id %s, Error: %s", ticketId, err.Error())
+		return err
+	}
+
+	// Transfer rules are checked again, show may have started or ticket may have been redeemed since the request
+	theatre, err := getTheatre(ctx, ticket.TheatreId)
+	if err != nil {
+		log.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+		return fmt.Errorf("Failed to get state for theatre id: %s, Got error: %s", ticket.TheatreId, err.Error())
+	} else if theatre == nil {
+		log.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+		return fmt.Errorf("Theatre with theatre id %s does not exist", ticket.TheatreId)
+	} else if err = checkTicketTransferRules(ctx, theatre, ticket); err != nil {
+		log.Errorf("Ticket id %s can not be transferred, Error: %s", ticketId, err.Error())
+		return err
+	}
+
+	previousOwnerMspId := ticket.OwnerMspId
+	newOwnerMspId := ticket.PendingTransfer.ToMspId
+	if err := changeTicketOwner(ctx, ticket, newOwnerMspId, ticket.PendingTransfer.ToId); err != nil {
+		log.Errorf("Failed to transfer ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to transfer ticket with ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	if err := setEvent(ctx, ticketTransferredEvent, getTicketTransferEventData(ticket, previousOwnerMspId, newOwnerMspId)); err != nil {
+		log.Errorf("Failed to set ticket transferred event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to set ticket transferred event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	log.Infof("Ticket with ticket id: %s transferred from %s to %s successfully !!", ticketId, previousOwnerMspId, newOwnerMspId)
+	return nil
+}
+
+/**
+	Method to cancel a pending transfer of a ticket, allowed for the owner and the receiving client
+*/
+func (s *MovieTicket) Cancel_ticket_transfer(ctx contractapi.TransactionContextInterface, ticketId string) error {
+	log := logging.MustGetLogger(name)
+
+	ticket, err := getTicket(ctx, ticketId)
+	if err != nil {
+		log.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to get state for ticket id: %s, Got error: %s", ticketId, err.Error())
+	} else if ticket == nil {
+		log.Errorf("Invalid ticket id %s", ticketId)
+		return fmt.Errorf("Invalid ticket id %s", ticketId)
+	} else if ticket.PendingTransfer == nil {
+		log.Errorf("Ticket id %s does not have a pending transfer", ticketId)
+		return fmt.Errorf("NO_PENDING_TRANSFER")
+	}
+
+	if err = checkTicketOwner(ctx, ticket); err != nil {
+		if err = checkClientIdentity(ctx, ticket.PendingTransfer.ToMspId, ticket.PendingTransfer.ToId); err != nil {
+			log.Errorf("Client is not authorised to cancel transfer of ticket id %s, Error: %s", ticketId, err.Error())
+			return err
+		}
+	}
+
+	toMspId := ticket.PendingTransfer.ToMspId
+	ticket.PendingTransfer = nil
+	ticketAsBytes, _ := json.Marshal(ticket)
+	if err := ctx.GetStub().PutState(ticketId, ticketAsBytes); err != nil {
+		log.Errorf("Failed to cancel transfer of ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to cancel transfer of ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	if err := setEvent(ctx, ticketTransferCancelledEvent, getTicketTransferEventData(ticket, ticket.OwnerMspId, toMspId)); err != nil {
+		log.Errorf("Failed to set ticket transfer cancelled event for ticket id: %s, Error: %s", ticketId, err.Error())
+		return fmt.Errorf("Failed to set ticket transfer cancelled event for ticket id: %s, Error: %s", ticketId, err.Error())
+	}
+
+	log.Infof("Transfer of ticket id: %s cancelled successfully !!", ticketId)
+	return nil
 }
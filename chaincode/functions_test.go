@@ -0,0 +1,767 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClient struct {
+	mspId string
+	id    string
+	role  string
+}
+
+func (c *testClient) GetID() (string, error)    { return c.id, nil }
+func (c *testClient) GetMSPID() (string, error) { return c.mspId, nil }
+func (c *testClient) GetAttributeValue(attrName string) (string, bool, error) {
+	if attrName != roleAttribute || c.role == "" {
+		return "", false, nil
+	}
+	return c.role, true, nil
+}
+func (c *testClient) AssertAttributeValue(attrName, attrValue string) error {
+	if value, found, _ := c.GetAttributeValue(attrName); !found || value != attrValue {
+		return errors.New("attribute value does not match")
+	}
+	return nil
+}
+func (c *testClient) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+var (
+	org1Admin     = &testClient{mspId: "Org1MSP", id: "org1admin", role: roleTheatreAdmin}
+	org1BoxOffice = &testClient{mspId: "Org1MSP", id: "org1boxoffice", role: roleBoxOffice}
+	org2Admin     = &testClient{mspId: "Org2MSP", id: "org2admin", role: roleTheatreAdmin}
+	org2BoxOffice = &testClient{mspId: "Org2MSP", id: "org2boxoffice", role: roleBoxOffice}
+	customer1     = &testClient{mspId: "Org3MSP", id: "customer1", role: roleCustomer}
+	customer2     = &testClient{mspId: "Org3MSP", id: "customer2", role: roleCustomer}
+	channelAdmin  = &testClient{mspId: "OrdererMSP", id: "channeladmin", role: roleChannelAdmin}
+)
+
+/**
+	Stub adding what MockStub does not implement: rich queries with equality and comparison operators,
+	deleting private data, history of keys and keeping every event
+*/
+type testStub struct {
+	*shimtest.MockStub
+	events  []*peer.ChaincodeEvent
+	history map[string][]*queryresult.KeyModification
+}
+
+type testQueryIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *testQueryIterator) HasNext() bool { return len(it.results) > 0 }
+func (it *testQueryIterator) Close() error  { return nil }
+func (it *testQueryIterator) Next() (*queryresult.KV, error) {
+	if len(it.results) == 0 {
+		return nil, errors.New("no more results")
+	}
+	result := it.results[0]
+	it.results = it.results[1:]
+	return result, nil
+}
+
+func (stub *testStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := stub.queryState(query, "", 0)
+	return &testQueryIterator{results: results}, err
+}
+
+func (stub *testStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	results, err := stub.queryState(query, bookmark, int(pageSize))
+	responseMetadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: bookmark}
+	if len(results) > 0 {
+		responseMetadata.Bookmark = results[len(results)-1].Key
+	}
+	return &testQueryIterator{results: results}, responseMetadata, err
+}
+
+type testHistoryIterator struct {
+	history []*queryresult.KeyModification
+}
+
+func (it *testHistoryIterator) HasNext() bool { return len(it.history) > 0 }
+func (it *testHistoryIterator) Close() error  { return nil }
+func (it *testHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if len(it.history) == 0 {
+		return nil, errors.New("no more history")
+	}
+	keyModification := it.history[0]
+	it.history = it.history[1:]
+	return keyModification, nil
+}
+
+func (stub *testStub) PutState(key string, value []byte) error {
+	if err := stub.MockStub.PutState(key, value); err != nil {
+		return err
+	}
+	stub.addHistory(key, value, false)
+	return nil
+}
+
+func (stub *testStub) DelState(key string) error {
+	if err := stub.MockStub.DelState(key); err != nil {
+		return err
+	}
+	stub.addHistory(key, nil, true)
+	return nil
+}
+
+/**
+	Function to add a version of a key, history is kept newest first as the peer returns it
+*/
+func (stub *testStub) addHistory(key string, value []byte, isDelete bool) {
+	if stub.history == nil {
+		stub.history = make(map[string][]*queryresult.KeyModification)
+	}
+	keyModification := &queryresult.KeyModification{TxId: stub.TxID, Value: value, Timestamp: stub.TxTimestamp, IsDelete: isDelete}
+	stub.history[key] = append([]*queryresult.KeyModification{keyModification}, stub.history[key]...)
+}
+
+func (stub *testStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &testHistoryIterator{history: stub.history[key]}, nil
+}
+
+func (stub *testStub) DelPrivateData(collection, key string) error {
+	delete(stub.PvtState[collection], key)
+	return nil
+}
+
+func (stub *testStub) SetEvent(name string, payload []byte) error {
+	stub.events = append(stub.events, &peer.ChaincodeEvent{EventName: name, Payload: payload})
+	return nil
+}
+
+/**
+	Function to get keys whose json value matches selector of a rich query, in key order
+*/
+func (stub *testStub) queryState(query, bookmark string, pageSize int) ([]*queryresult.KV, error) {
+	var richQuery RichQuery
+	if err := json.Unmarshal([]byte(query), &richQuery); err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range stub.State {
+		if key > bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	results := []*queryresult.KV{}
+	for _, key := range keys {
+		var document map[string]interface{}
+		if json.Unmarshal(stub.State[key], &document) != nil || !matchesSelector(document, richQuery.Selector) {
+			continue
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: stub.State[key]})
+		if pageSize > 0 && len(results) == pageSize {
+			break
+		}
+	}
+	return results, nil
+}
+
+func matchesSelector(document, selector map[string]interface{}) bool {
+	for field, condition := range selector {
+		value, found := document[field]
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if !found || fmt.Sprint(value) != fmt.Sprint(condition) {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			if !found || value == nil {
+				return false
+			}
+			switch operator {
+			case "$in":
+				in := false
+				for _, option := range operand.([]interface{}) {
+					in = in || fmt.Sprint(value) == fmt.Sprint(option)
+				}
+				if !in {
+					return false
+				}
+			case "$gt":
+				if operand != nil && compareValues(value, operand) <= 0 {
+					return false
+				}
+			case "$gte":
+				if compareValues(value, operand) < 0 {
+					return false
+				}
+			case "$lt":
+				if compareValues(value, operand) >= 0 {
+					return false
+				}
+			case "$lte":
+				if compareValues(value, operand) > 0 {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareValues(a, b interface{}) int {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			if x < y {
+				return -1
+			} else if x > y {
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+/**
+	Ledger shared by the transactions of a test, every transaction gets a new transaction id and the current test time
+*/
+type testLedger struct {
+	t     *testing.T
+	stub  *testStub
+	txNo  int
+	now   time.Time
+	chain *MovieTicket
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	stub := &testStub{MockStub: shimtest.NewMockStub(name, nil)}
+	now, _ := time.Parse(time.RFC3339, "2030-01-01T10:00:00Z")
+	return &testLedger{t: t, stub: stub, now: now, chain: new(MovieTicket)}
+}
+
+/**
+	Function to start a transaction invoked by the client, transient data is passed as key and value pairs
+*/
+func (l *testLedger) ctx(client *testClient, transient ...string) contractapi.TransactionContextInterface {
+	l.txNo++
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%03d", l.txNo))
+	l.stub.TxTimestamp = timestamppb.New(l.now)
+	l.stub.TransientMap = map[string][]byte{}
+	for i := 0; i+1 < len(transient); i += 2 {
+		l.stub.TransientMap[transient[i]] = []byte(transient[i+1])
+	}
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(l.stub)
+	ctx.SetClientIdentity(client)
+	return ctx
+}
+
+func (l *testLedger) lastEvent() string {
+	if len(l.stub.events) == 0 {
+		return ""
+	}
+	return l.stub.events[len(l.stub.events)-1].EventName
+}
+
+func (l *testLedger) lastEventPayload() *Event {
+	event := new(Event)
+	if len(l.stub.events) > 0 {
+		json.Unmarshal(l.stub.events[len(l.stub.events)-1].Payload, &event)
+	}
+	return event
+}
+
+/**
+	Function to write a key as chaincode versions before the current one did
+*/
+func (l *testLedger) putLegacyState(key, value string) {
+	l.t.Helper()
+	l.ctx(org1Admin)
+	l.mustSucceed(l.stub.PutState(key, []byte(value)), "PutState")
+	l.stub.MockTransactionEnd("tx")
+}
+
+func (l *testLedger) mustSucceed(err error, action string) {
+	l.t.Helper()
+	if err != nil {
+		l.t.Fatalf("%s failed: %s", action, err.Error())
+	}
+}
+
+func (l *testLedger) mustFail(err error, code, action string) {
+	l.t.Helper()
+	if err == nil {
+		l.t.Fatalf("%s succeeded, expected %s", action, code)
+	} else if !strings.Contains(err.Error(), code) {
+		l.t.Fatalf("%s failed with %s, expected %s", action, err.Error(), code)
+	}
+}
+
+func toJson(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+/**
+	Function to register theatre1 of Org1 with two movie halls of 10 seats and a show on 2030-01-02 at 18:00 in movie hall 1
+*/
+func (l *testLedger) setupTheatre() {
+	l.t.Helper()
+	l.mustSucceed(l.chain.Register_theatre(l.ctx(org1Admin), `{"theatreId":"theatre1","movieHallNos":2,"ticketsPerShow":10,"ticketWindowNos":1,"timeZone":"UTC","salesCloseMinutes":15}`), "Register_theatre")
+	l.mustSucceed(l.chain.Register_show(l.ctx(org1Admin), `{"theatreId":"theatre1","movieHallNo":1,"showId":"show1","showName":"Show 1","showStartDate":"2030-01-02","showEndDate":"2030-01-02","showTime":"18:00","runtimeMinutes":120,"prices":{"standard":500}}`), "Register_show")
+}
+
+func bookingJson(noOfSeats int, seats ...string) string {
+	ticket := Ticket{TheatreId: "theatre1", ShowId: "show1", ShowDate: "2030-01-02", ShowTime: "18:00", MovieHallNo: 1, NoOfSeats: noOfSeats, Seats: seats}
+	return toJson(ticket)
+}
+
+func TestSeatMapRejectsAmbiguousRows(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	seatMap := SeatMap{TheatreId: "theatre1", MovieHallNo: 2, Seats: []Seat{{Row: "A1", SeatNo: 1, SeatType: seatTypeStandard}, {Row: "A", SeatNo: 11, SeatType: seatTypeStandard}}}
+	l.mustFail(l.chain.Register_seat_map(l.ctx(org1Admin), toJson(seatMap)), "Invalid seat", "Register_seat_map with row A1")
+
+	seatMap.Seats[0].Row = "B"
+	l.mustFail(l.chain.Register_seat_map(l.ctx(org2Admin), toJson(seatMap)), "ACCESS_DENIED", "Register_seat_map by another organisation")
+	l.mustSucceed(l.chain.Register_seat_map(l.ctx(org1Admin), toJson(seatMap)), "Register_seat_map")
+}
+
+func (l *testLedger) cafeteriaStock(sku string) int {
+	l.t.Helper()
+	cafeteriaItem, err := getCafeteriaItem(l.ctx(org1Admin), "theatre1", sku)
+	l.mustSucceed(err, "getCafeteriaItem")
+	return cafeteriaItem.Stock
+}
+
+func TestCancelTicketByOwnerCancelsCafeteriaOrders(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.mustSucceed(l.chain.Set_cafeteria_item(l.ctx(org1Admin), `{"theatreId":"theatre1","sku":"POPCORN","name":"Popcorn","unitPrice":200,"stock":10}`), "Set_cafeteria_item")
+
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(2))
+	l.mustSucceed(err, "Book_ticket")
+	orderId, err := l.chain.Place_cafeteria_order(l.ctx(customer1), `{"ticketId":"`+ticketId+`","items":[{"sku":"POPCORN","quantity":3}]}`)
+	l.mustSucceed(err, "Place_cafeteria_order")
+	if stock := l.cafeteriaStock("POPCORN"); stock != 7 {
+		t.Fatalf("Stock after order is %d, expected 7", stock)
+	}
+
+	l.mustFail(l.chain.Cancel_ticket(l.ctx(customer2), ticketId), "ACCESS_DENIED", "Cancel_ticket by another customer")
+	l.mustSucceed(l.chain.Cancel_ticket(l.ctx(customer1), ticketId), "Cancel_ticket by ticket owner")
+	if l.lastEvent() != ticketCancelledEvent {
+		t.Fatalf("Last event is %s, expected %s", l.lastEvent(), ticketCancelledEvent)
+	}
+
+	cafeteriaOrder := new(CafeteriaOrder)
+	json.Unmarshal(l.stub.State[orderId], &cafeteriaOrder)
+	if cafeteriaOrder.Status != orderStatusCancelled {
+		t.Fatalf("Order status is %s, expected %s", cafeteriaOrder.Status, orderStatusCancelled)
+	} else if stock := l.cafeteriaStock("POPCORN"); stock != 10 {
+		t.Fatalf("Stock after cancellation is %d, expected 10", stock)
+	}
+	l.mustFail(l.chain.Update_cafeteria_order_status(l.ctx(org1BoxOffice), orderId, orderStatusPrepared), "INVALID_ORDER_STATUS", "Preparing a cancelled order")
+}
+
+func TestLegacyTicketsCountTowardsSoldSeats(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	// Ticket booked before ticket status and seat counters were introduced
+	l.ctx(org1BoxOffice)
+	legacyTicket := Ticket{TicketId: "legacy1", TheatreId: "theatre1", ShowId: "show1", ShowDate: "2030-01-02", ShowTime: "18:00", MovieHallNo: 1, NoOfSeats: 8, RecordType: 2}
+	l.mustSucceed(l.stub.PutState(legacyTicket.TicketId, []byte(toJson(legacyTicket))), "PutState")
+	l.stub.MockTransactionEnd("tx")
+
+	_, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(3))
+	l.mustFail(err, "SEATS_NOT_AVAILABLE", "Book_ticket beyond seats left by legacy ticket")
+	_, err = l.chain.Book_ticket(l.ctx(customer1), bookingJson(2))
+	l.mustSucceed(err, "Book_ticket")
+
+	l.mustSucceed(l.chain.Cancel_ticket(l.ctx(org1BoxOffice), "legacy1"), "Cancel_ticket of legacy ticket")
+	showSeatCount, err := getShowSeatCount(l.ctx(org1Admin), "theatre1", "2030-01-02", "18:00", 1)
+	l.mustSucceed(err, "getShowSeatCount")
+	if showSeatCount.SoldSeats != 2 || showSeatCount.Bookings != 2 {
+		t.Fatalf("Seat counter is %+v, expected 2 sold seats and 2 bookings", *showSeatCount)
+	}
+}
+
+func TestBookTicketIgnoresClientTicketId(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket")
+
+	ticket := Ticket{TicketId: ticketId, TheatreId: "theatre1", ShowId: "show1", ShowDate: "2030-01-02", ShowTime: "18:00", MovieHallNo: 1, NoOfSeats: 1}
+	otherTicketId, err := l.chain.Book_ticket(l.ctx(customer2), toJson(ticket))
+	l.mustSucceed(err, "Book_ticket with id of another ticket")
+	if otherTicketId == ticketId {
+		t.Fatalf("Ticket %s was overwritten", ticketId)
+	} else if ticket, _ := getTicket(l.ctx(customer1), ticketId); ticket.OwnerId != customer1.id {
+		t.Fatalf("Ticket %s is owned by %s, expected %s", ticketId, ticket.OwnerId, customer1.id)
+	}
+}
+
+func TestLegacyTheatreOwnerIsAssignedOnce(t *testing.T) {
+	l := newTestLedger(t)
+
+	// Theatre registered before theatre ownership was recorded
+	l.ctx(org1Admin)
+	l.mustSucceed(l.stub.PutState("legacy", []byte(`{"theatreId":"legacy","movieHallNos":1,"ticketsPerShow":10,"ticketWindowNos":1,"RecordType":3}`)), "PutState")
+	l.stub.MockTransactionEnd("tx")
+	l.mustFail(l.chain.Set_sales_window(l.ctx(org1Admin), "legacy", 7, 15), "ACCESS_DENIED", "Set_sales_window of theatre without owner")
+
+	l.mustFail(l.chain.Assign_theatre_owner(l.ctx(org1Admin), "legacy", "Org1MSP", org1Admin.id), "ACCESS_DENIED", "Assign_theatre_owner by theatre admin")
+	l.mustSucceed(l.chain.Assign_theatre_owner(l.ctx(channelAdmin), "legacy", "Org1MSP", org1Admin.id), "Assign_theatre_owner")
+	l.mustSucceed(l.chain.Set_sales_window(l.ctx(org1Admin), "legacy", 7, 15), "Set_sales_window by assigned owner")
+	l.mustFail(l.chain.Assign_theatre_owner(l.ctx(channelAdmin), "legacy", "Org2MSP", org2Admin.id), "THEATRE_ALREADY_OWNED", "Assign_theatre_owner of owned theatre")
+}
+
+func TestTheatreTransferNeedsAcceptanceOfNewOwner(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	l.mustFail(l.chain.Transfer_theatre_ownership(l.ctx(org1Admin), "theatre1", "Org1MSP", "another"), "INVALID_NEW_OWNER", "Transfer_theatre_ownership to the same owner")
+	l.mustFail(l.chain.Transfer_theatre_ownership(l.ctx(org2Admin), "theatre1", "Org2MSP", org2Admin.id), "ACCESS_DENIED", "Transfer_theatre_ownership by another organisation")
+	l.mustSucceed(l.chain.Transfer_theatre_ownership(l.ctx(org1Admin), "theatre1", "Org2MSP", org2Admin.id), "Transfer_theatre_ownership")
+	if l.lastEvent() != theatreTransferRequestedEvent {
+		t.Fatalf("Last event is %s, expected %s", l.lastEvent(), theatreTransferRequestedEvent)
+	}
+
+	// Theatre stays with the current owner until the new owner accepts
+	l.mustSucceed(l.chain.Set_sales_window(l.ctx(org1Admin), "theatre1", 7, 15), "Set_sales_window by current owner")
+	l.mustFail(l.chain.Accept_theatre_ownership(l.ctx(org2BoxOffice), "theatre1"), "ACCESS_DENIED", "Accept_theatre_ownership by box office")
+	l.mustSucceed(l.chain.Accept_theatre_ownership(l.ctx(org2Admin), "theatre1"), "Accept_theatre_ownership")
+	if l.lastEvent() != theatreTransferredEvent {
+		t.Fatalf("Last event is %s, expected %s", l.lastEvent(), theatreTransferredEvent)
+	}
+	l.mustFail(l.chain.Set_sales_window(l.ctx(org1Admin), "theatre1", 7, 15), "ACCESS_DENIED", "Set_sales_window by previous owner")
+	l.mustSucceed(l.chain.Set_sales_window(l.ctx(org2Admin), "theatre1", 7, 15), "Set_sales_window by new owner")
+	l.mustFail(l.chain.Accept_theatre_ownership(l.ctx(org2Admin), "theatre1"), "NO_PENDING_TRANSFER", "Accepting a completed transfer")
+}
+
+func TestCustomerDetailsRequireSalt(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	_, err := l.chain.Book_ticket(l.ctx(customer1, customerDetailsTransientKey, `{"name":"Customer","phone":"9999999999"}`), bookingJson(1))
+	l.mustFail(err, "INVALID_SALT", "Book_ticket with customer details without salt")
+	_, err = l.chain.Book_ticket(l.ctx(customer1, customerDetailsTransientKey, `{"name":"Customer","phone":"9999999999","salt":"short"}`), bookingJson(1))
+	l.mustFail(err, "INVALID_SALT", "Book_ticket with customer details with short salt")
+
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1, customerDetailsTransientKey, `{"name":"Customer","phone":"9999999999","salt":"6f1c2a9e0b7d4e35"}`), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket with customer details")
+	customerDetails, err := l.chain.Get_customer_details(l.ctx(org1BoxOffice), ticketId)
+	l.mustSucceed(err, "Get_customer_details")
+	if customerDetails.Name != "Customer" {
+		t.Fatalf("Customer name is %s, expected Customer", customerDetails.Name)
+	}
+}
+
+func (l *testLedger) cafeteriaOrder(orderId string) *CafeteriaOrder {
+	cafeteriaOrder := new(CafeteriaOrder)
+	json.Unmarshal(l.stub.State[orderId], &cafeteriaOrder)
+	return cafeteriaOrder
+}
+
+func TestRescheduleAndCancelShowMoveHoldsAndOrders(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.mustSucceed(l.chain.Set_cafeteria_item(l.ctx(org1Admin), `{"theatreId":"theatre1","sku":"POPCORN","name":"Popcorn","unitPrice":200,"stock":10}`), "Set_cafeteria_item")
+
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(2))
+	l.mustSucceed(err, "Book_ticket")
+	orderId, err := l.chain.Place_cafeteria_order(l.ctx(customer1), `{"ticketId":"`+ticketId+`","items":[{"sku":"POPCORN","quantity":4}]}`)
+	l.mustSucceed(err, "Place_cafeteria_order")
+	holdId, err := l.chain.Hold_seats(l.ctx(customer2), bookingJson(3))
+	l.mustSucceed(err, "Hold_seats")
+
+	l.mustFail(l.chain.Reschedule_show(l.ctx(org2Admin), `{"theatreId":"theatre1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":1,"newMovieHallNo":2,"moveTickets":true}`), "ACCESS_DENIED", "Reschedule_show by another organisation")
+	l.mustSucceed(l.chain.Reschedule_show(l.ctx(org1Admin), `{"theatreId":"theatre1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":1,"newMovieHallNo":2,"moveTickets":true}`), "Reschedule_show")
+
+	// Order moves with its ticket, seat hold of the show is released
+	if cafeteriaOrder := l.cafeteriaOrder(orderId); cafeteriaOrder.MovieHallNo != 2 || cafeteriaOrder.Status != orderStatusPlaced {
+		t.Fatalf("Order is in movie hall %d with status %s, expected movie hall 2 with status %s", cafeteriaOrder.MovieHallNo, cafeteriaOrder.Status, orderStatusPlaced)
+	}
+	_, err = l.chain.Confirm_hold(l.ctx(customer2), holdId)
+	l.mustFail(err, "does not exist", "Confirm_hold of released seat hold")
+	showSeatCount, err := getShowSeatCount(l.ctx(org1Admin), "theatre1", "2030-01-02", "18:00", 2)
+	l.mustSucceed(err, "getShowSeatCount")
+	if showSeatCount.SoldSeats != 2 || len(showSeatCount.Holds) != 0 {
+		t.Fatalf("Seat counter of rescheduled show is %+v, expected 2 sold seats and no holds", *showSeatCount)
+	}
+
+	// Cancelling the show cancels the order and returns its items to stock
+	l.mustFail(l.chain.Cancel_show(l.ctx(org1BoxOffice), `{"theatreId":"theatre1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":2}`), "ACCESS_DENIED", "Cancel_show by box office")
+	l.mustSucceed(l.chain.Cancel_show(l.ctx(org1Admin), `{"theatreId":"theatre1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":2}`), "Cancel_show")
+	if cafeteriaOrder := l.cafeteriaOrder(orderId); cafeteriaOrder.Status != orderStatusCancelled {
+		t.Fatalf("Order status is %s, expected %s", cafeteriaOrder.Status, orderStatusCancelled)
+	} else if stock := l.cafeteriaStock("POPCORN"); stock != 10 {
+		t.Fatalf("Stock after show cancellation is %d, expected 10", stock)
+	} else if ticket, _ := getTicket(l.ctx(customer1), ticketId); ticket.Status != ticketStatusRefundEligible {
+		t.Fatalf("Ticket status is %s, expected %s", ticket.Status, ticketStatusRefundEligible)
+	}
+}
+
+func TestShowWithoutPricesIsFree(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.mustSucceed(l.chain.Register_show(l.ctx(org1Admin), `{"theatreId":"theatre1","movieHallNo":2,"showId":"show2","showName":"Show 2","showStartDate":"2030-01-02","showEndDate":"2030-01-02","showTime":"18:00","runtimeMinutes":120}`), "Register_show without prices")
+
+	ticket := Ticket{TheatreId: "theatre1", ShowId: "show2", ShowDate: "2030-01-02", ShowTime: "18:00", MovieHallNo: 2, NoOfSeats: 2}
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1), toJson(ticket))
+	l.mustSucceed(err, "Book_ticket for show without prices")
+	if ticket, _ := getTicket(l.ctx(customer1), ticketId); ticket.TotalPrice != 0 {
+		t.Fatalf("Total price is %d, expected 0", ticket.TotalPrice)
+	}
+}
+
+func TestLegacySodaStockAndReplacementsAreHonoured(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.putLegacyState(legacyCafeteriaKeyPrefix+"theatre1", `{"sodaBottleQuantity":5}`)
+
+	l.mustFail(l.chain.Migrate_cafeteria_stock(l.ctx(org2Admin), "theatre1"), "ACCESS_DENIED", "Migrate_cafeteria_stock by another organisation")
+	l.mustSucceed(l.chain.Migrate_cafeteria_stock(l.ctx(org1Admin), "theatre1"), "Migrate_cafeteria_stock")
+	if stock := l.cafeteriaStock(sodaBottleSku); stock != 5 {
+		t.Fatalf("Soda bottle stock is %d, expected 5", stock)
+	} else if version := l.lastEventPayload().Version; version != cafeteriaInventoryAddedVersion {
+		t.Fatalf("Version of %s event is %s, expected %s", l.lastEvent(), version, cafeteriaInventoryAddedVersion)
+	}
+	l.mustFail(l.chain.Migrate_cafeteria_stock(l.ctx(org1Admin), "theatre1"), "ALREADY_MIGRATED", "Migrate_cafeteria_stock again")
+	l.mustSucceed(l.chain.Register_promotion(l.ctx(org1Admin), `{"promotionId":"soda","theatreId":"theatre1","sku":"SODA"}`), "Register_promotion")
+
+	// Soda bottle given before the cafeteria catalogue can not be given again
+	replacedTicketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket")
+	l.putLegacyState(legacyReplacementKeyPrefix+replacedTicketId, `{"ticketId":"`+replacedTicketId+`"}`)
+	_, err = l.chain.Replace_with_soda_bottle(l.ctx(org1BoxOffice), replacedTicketId)
+	l.mustFail(err, "ALREADY_REDEEMED", "Replace_with_soda_bottle of ticket replaced before the catalogue")
+
+	// Cancelling the ticket returns its soda bottle to stock and removes the legacy replacement
+	l.mustSucceed(l.chain.Cancel_ticket(l.ctx(customer1), replacedTicketId), "Cancel_ticket of ticket replaced before the catalogue")
+	if stock := l.cafeteriaStock(sodaBottleSku); stock != 6 {
+		t.Fatalf("Soda bottle stock after cancellation is %d, expected 6", stock)
+	} else if redeemed, _ := hasRedemptions(l.ctx(org1BoxOffice), replacedTicketId); redeemed {
+		t.Fatalf("Cancelled ticket %s still has its soda bottle redeemed", replacedTicketId)
+	}
+
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket")
+	_, err = l.chain.Replace_with_soda_bottle(l.ctx(org1BoxOffice), ticketId)
+	l.mustSucceed(err, "Replace_with_soda_bottle")
+	event := l.lastEventPayload()
+	if l.lastEvent() != sodaBottleReplacedEvent || event.Version != eventSchemaVersion {
+		t.Fatalf("Last event is %s version %s, expected %s version %s", l.lastEvent(), event.Version, sodaBottleReplacedEvent, eventSchemaVersion)
+	} else if data := event.Data.(map[string]interface{}); data["sodaBottleQuantity"] != float64(5) {
+		t.Fatalf("Soda bottle quantity in event is %v, expected 5", data["sodaBottleQuantity"])
+	}
+}
+
+func TestLuckyDrawLeavesOutTicketsBookedBeforeCommitment(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	showSlot := `{"theatreId":"theatre1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":1}`
+	seed := "theatre1-show1-seed"
+	seedHash := sha256.Sum256([]byte(seed))
+
+	earlyTicketId, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket before commitment")
+	l.mustFail(l.chain.Commit_lucky_draw_seed(l.ctx(org2Admin), showSlot, hex.EncodeToString(seedHash[:])), "ACCESS_DENIED", "Commit_lucky_draw_seed by another organisation")
+	l.mustSucceed(l.chain.Commit_lucky_draw_seed(l.ctx(org1Admin), showSlot, hex.EncodeToString(seedHash[:])), "Commit_lucky_draw_seed after a booking")
+	ticketId, err := l.chain.Book_ticket(l.ctx(customer2), bookingJson(1))
+	l.mustSucceed(err, "Book_ticket after commitment")
+
+	// Ticket booked before lucky no. was drawn by chaincode
+	l.putLegacyState("legacy1", toJson(Ticket{TicketId: "legacy1", TheatreId: "theatre1", ShowId: "show1", ShowDate: "2030-01-02", ShowTime: "18:00", MovieHallNo: 1, NoOfSeats: 1, LuckyNo: 42, RecordType: 2}))
+
+	l.now = l.now.Add(33 * time.Hour)
+	l.mustSucceed(l.chain.Reveal_lucky_draw_seed(l.ctx(org1Admin), showSlot, seed), "Reveal_lucky_draw_seed")
+	for _, id := range []string{earlyTicketId, ticketId, "legacy1"} {
+		ticket, err := l.chain.Get_ticket(l.ctx(org1BoxOffice), id)
+		l.mustSucceed(err, "Get_ticket")
+		if id == ticketId && ticket.LuckyNo != getLuckyNo(seed, ticket.BookingTxId) {
+			t.Fatalf("Lucky no. of ticket booked after commitment is %d, expected %d", ticket.LuckyNo, getLuckyNo(seed, ticket.BookingTxId))
+		} else if id != ticketId && ticket.LuckyNo != 0 {
+			t.Fatalf("Lucky no. of ticket %s is %d, expected 0", id, ticket.LuckyNo)
+		}
+	}
+
+	// Owner sees the lucky no. in its tickets and in every version of the ticket
+	paginatedTicketList, err := l.chain.Get_my_tickets(l.ctx(customer2), "", 10, "")
+	l.mustSucceed(err, "Get_my_tickets")
+	ticketHistory, err := l.chain.Get_ticket_history(l.ctx(customer2), ticketId)
+	l.mustSucceed(err, "Get_ticket_history")
+	if len(paginatedTicketList.Tickets) != 1 || paginatedTicketList.Tickets[0].LuckyNo != getLuckyNo(seed, paginatedTicketList.Tickets[0].BookingTxId) {
+		t.Fatalf("Tickets of owner are %+v, expected ticket %s with its lucky no.", paginatedTicketList.Tickets, ticketId)
+	} else if ticketHistory.History[0].Value.LuckyNo != paginatedTicketList.Tickets[0].LuckyNo {
+		t.Fatalf("Lucky no. in history is %d, expected %d", ticketHistory.History[0].Value.LuckyNo, paginatedTicketList.Tickets[0].LuckyNo)
+	}
+}
+
+func TestMyTicketsAreFilteredByShowStartInTheatreTimeZone(t *testing.T) {
+	l := newTestLedger(t)
+	l.mustSucceed(l.chain.Register_theatre(l.ctx(org2Admin), `{"theatreId":"theatre2","movieHallNos":1,"ticketsPerShow":10,"ticketWindowNos":1,"timeZone":"Asia/Kolkata","salesCloseMinutes":15}`), "Register_theatre")
+	l.mustSucceed(l.chain.Register_show(l.ctx(org2Admin), `{"theatreId":"theatre2","movieHallNo":1,"showId":"show1","showName":"Show 1","showStartDate":"2030-01-01","showEndDate":"2030-01-02","showTime":"16:00","runtimeMinutes":120,"prices":{"standard":500}}`), "Register_show")
+
+	// Show on 2030-01-01 at 16:00 in Kolkata starts at 10:30 UTC
+	ticket := Ticket{TheatreId: "theatre2", ShowId: "show1", ShowDate: "2030-01-01", ShowTime: "16:00", MovieHallNo: 1, NoOfSeats: 1}
+	pastTicketId, err := l.chain.Book_ticket(l.ctx(customer1), toJson(ticket))
+	l.mustSucceed(err, "Book_ticket")
+	ticket.ShowDate = "2030-01-02"
+	cancelledTicketId, err := l.chain.Book_ticket(l.ctx(customer1), toJson(ticket))
+	l.mustSucceed(err, "Book_ticket")
+	l.mustSucceed(l.chain.Cancel_ticket(l.ctx(customer1), cancelledTicketId), "Cancel_ticket")
+	upcomingTicketId, err := l.chain.Book_ticket(l.ctx(customer1), toJson(ticket))
+	l.mustSucceed(err, "Book_ticket")
+	if ticket, _ := getTicket(l.ctx(customer1), pastTicketId); ticket.ShowStartsAt != "2030-01-01T10:30:00Z" {
+		t.Fatalf("Show start of ticket is %s, expected 2030-01-01T10:30:00Z", ticket.ShowStartsAt)
+	}
+
+	// A page holds only tickets matching the filter
+	l.now = l.now.Add(time.Hour)
+	for filter, expectedTicketId := range map[string]string{ticketFilterUpcoming: upcomingTicketId, ticketFilterPast: pastTicketId} {
+		paginatedTicketList, err := l.chain.Get_my_tickets(l.ctx(customer1), filter, 1, "")
+		l.mustSucceed(err, "Get_my_tickets")
+		if len(paginatedTicketList.Tickets) != 1 || paginatedTicketList.Tickets[0].TicketId != expectedTicketId {
+			t.Fatalf("%s tickets are %+v, expected only %s", filter, paginatedTicketList.Tickets, expectedTicketId)
+		}
+	}
+	paginatedTicketList, err := l.chain.Get_my_tickets(l.ctx(customer1), "", 10, "")
+	l.mustSucceed(err, "Get_my_tickets")
+	if len(paginatedTicketList.Tickets) != 3 {
+		t.Fatalf("Got %d tickets, expected 3", len(paginatedTicketList.Tickets))
+	}
+	paginatedTicketList, err = l.chain.Get_my_tickets(l.ctx(customer2), ticketFilterUpcoming, 10, "")
+	l.mustSucceed(err, "Get_my_tickets")
+	if len(paginatedTicketList.Tickets) != 0 {
+		t.Fatalf("Got %d tickets of another client, expected none", len(paginatedTicketList.Tickets))
+	}
+}
+
+func TestSeatHoldsAreCappedPerClientAndPrunedOnBooking(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+
+	holdIds := []string{}
+	for i := 0; i < maxActiveSeatHolds; i++ {
+		holdId, err := l.chain.Hold_seats(l.ctx(customer1), bookingJson(2))
+		l.mustSucceed(err, "Hold_seats")
+		holdIds = append(holdIds, holdId)
+	}
+	_, err := l.chain.Hold_seats(l.ctx(customer1), bookingJson(1))
+	l.mustFail(err, "TOO_MANY_HOLDS", "Hold_seats beyond the limit")
+
+	// Confirmed seat hold no longer counts towards the limit
+	_, err = l.chain.Confirm_hold(l.ctx(customer2), holdIds[0])
+	l.mustFail(err, "ACCESS_DENIED", "Confirm_hold by another client")
+	_, err = l.chain.Confirm_hold(l.ctx(customer1), holdIds[0])
+	l.mustSucceed(err, "Confirm_hold")
+	_, err = l.chain.Hold_seats(l.ctx(customer1), bookingJson(2))
+	l.mustSucceed(err, "Hold_seats after confirming a seat hold")
+
+	// 2 seats are sold and 6 held out of 10
+	_, err = l.chain.Book_ticket(l.ctx(customer2), bookingJson(3))
+	l.mustFail(err, "SEATS_NOT_AVAILABLE", "Book_ticket of held seats")
+
+	// Booking after the seat holds expire removes them from the seat counter
+	l.now = l.now.Add((seatHoldMinutes + 1) * time.Minute)
+	_, err = l.chain.Book_ticket(l.ctx(customer2), bookingJson(3))
+	l.mustSucceed(err, "Book_ticket after seat holds expired")
+	showSeatCount, err := getShowSeatCount(l.ctx(org1Admin), "theatre1", "2030-01-02", "18:00", 1)
+	l.mustSucceed(err, "getShowSeatCount")
+	if showSeatCount.SoldSeats != 5 || len(showSeatCount.Holds) != 0 {
+		t.Fatalf("Seat counter is %+v, expected 5 sold seats and no holds", *showSeatCount)
+	} else if seatHold, _ := getSeatHold(l.ctx(org1Admin), holdIds[1]); seatHold != nil {
+		t.Fatalf("Expired seat hold %s is not deleted", holdIds[1])
+	} else if noOfHolds, _ := countActiveSeatHolds(l.ctx(customer1), customer1.mspId, customer1.id, l.now); noOfHolds != 0 {
+		t.Fatalf("Client has %d active seat holds, expected 0", noOfHolds)
+	}
+}
+
+func TestShowPagesHoldOnlyShowsOnSale(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.mustSucceed(l.chain.Register_show(l.ctx(org1Admin), `{"theatreId":"theatre1","movieHallNo":2,"showId":"show2","showName":"Show 2","showStartDate":"2030-01-02","showEndDate":"2030-01-04","showTime":"10:00","runtimeMinutes":120}`), "Register_show")
+
+	// Sales of both shows on 2030-01-02 are closed
+	l.now, _ = time.Parse(time.RFC3339, "2030-01-02T20:00:00Z")
+	shows := []string{}
+	bookmark := ""
+	for page := 0; page < 3; page++ {
+		paginatedShowSearchResult, err := l.chain.Get_shows_with_pagination(l.ctx(customer1), `{"theatreId":"theatre1"}`, 1, bookmark)
+		l.mustSucceed(err, "Get_shows_with_pagination")
+		if int(paginatedShowSearchResult.FetchedRecordsCount) != len(paginatedShowSearchResult.ShowList) {
+			t.Fatalf("Fetched records count is %d for %d shows", paginatedShowSearchResult.FetchedRecordsCount, len(paginatedShowSearchResult.ShowList))
+		}
+		for _, show := range paginatedShowSearchResult.ShowList {
+			shows = append(shows, show.ShowDate)
+		}
+		bookmark = paginatedShowSearchResult.Bookmark
+	}
+	if strings.Join(shows, ",") != "2030-01-03,2030-01-04" {
+		t.Fatalf("Shows on sale are %v, expected shows on 2030-01-03 and 2030-01-04", shows)
+	}
+
+	// Longer sales window puts the show which started 2 hours ago on sale again
+	l.mustFail(l.chain.Set_sales_window(l.ctx(org2Admin), "theatre1", 0, 240), "ACCESS_DENIED", "Set_sales_window by another organisation")
+	l.mustSucceed(l.chain.Set_sales_window(l.ctx(org1Admin), "theatre1", 0, 240), "Set_sales_window")
+	showSearchResult, err := l.chain.Get_shows(l.ctx(customer1), `{"theatreId":"theatre1","showDate":"2030-01-02"}`)
+	l.mustSucceed(err, "Get_shows")
+	if len(showSearchResult.ShowList) != 1 || showSearchResult.ShowList[0].ShowTime != "18:00" {
+		t.Fatalf("Shows on sale on 2030-01-02 are %+v, expected only the show at 18:00", showSearchResult.ShowList)
+	}
+}
+
+func TestSeatAvailabilityKeepsNoOfSeatsResult(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	_, err := l.chain.Book_ticket(l.ctx(customer1), bookingJson(2))
+	l.mustSucceed(err, "Book_ticket")
+
+	query := `{"theatreId":"theatre1","showId":"show1","showDate":"2030-01-02","showTime":"18:00","movieHallNo":1}`
+	availableSeats, err := l.chain.Get_seat_availability(l.ctx(customer2), query)
+	l.mustSucceed(err, "Get_seat_availability")
+	seatAvailability, err := l.chain.Get_free_seats(l.ctx(customer2), query)
+	l.mustSucceed(err, "Get_free_seats")
+	if availableSeats != 8 || seatAvailability.AvailableSeats != 8 {
+		t.Fatalf("Available seats are %d and %d, expected 8", availableSeats, seatAvailability.AvailableSeats)
+	}
+}
+
+func TestCafeteriaInventoryOfSodaBottlesAndOtherItems(t *testing.T) {
+	l := newTestLedger(t)
+	l.setupTheatre()
+	l.mustSucceed(l.chain.Set_cafeteria_item(l.ctx(org1Admin), `{"theatreId":"theatre1","sku":"POPCORN","name":"Popcorn","unitPrice":200,"stock":10}`), "Set_cafeteria_item")
+
+	// Soda bottles can be added only once the theatre has the soda bottle item
+	l.mustFail(l.chain.Add_cafeteria_inventory(l.ctx(org1Admin), "theatre1", 5), "INVALID_SKU", "Add_cafeteria_inventory without soda bottle item")
+	l.putLegacyState(legacyCafeteriaKeyPrefix+"theatre1", `{"sodaBottleQuantity":0}`)
+	l.mustSucceed(l.chain.Migrate_cafeteria_stock(l.ctx(org1Admin), "theatre1"), "Migrate_cafeteria_stock")
+	l.mustFail(l.chain.Add_cafeteria_inventory(l.ctx(org2Admin), "theatre1", 5), "ACCESS_DENIED", "Add_cafeteria_inventory by another organisation")
+	l.mustSucceed(l.chain.Add_cafeteria_inventory(l.ctx(org1Admin), "theatre1", 5), "Add_cafeteria_inventory")
+	l.mustSucceed(l.chain.Add_cafeteria_item_inventory(l.ctx(org1Admin), "theatre1", "POPCORN", -2), "Add_cafeteria_item_inventory")
+	if stock := l.cafeteriaStock(sodaBottleSku); stock != 5 {
+		t.Fatalf("Soda bottle stock is %d, expected 5", stock)
+	} else if stock = l.cafeteriaStock("POPCORN"); stock != 8 {
+		t.Fatalf("Popcorn stock is %d, expected 8", stock)
+	}
+}
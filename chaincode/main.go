@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/op/go-logging"
+	_ "time/tzdata" // Embed time zone database so that every peer resolves theatre time zones the same way
 )
 
 type MovieTicket struct {
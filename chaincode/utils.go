@@ -1,11 +1,18 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"sort"
 	"strconv"
+	"time"
+	"unicode"
 )
 
 /**
@@ -20,79 +27,1735 @@ func getCompositeKey(ctx contractapi.TransactionContextInterface, compositeKeyIn
 }
 
 /**
-	Function to create rich query string
+	Function to check whether invoking client has one of the given roles
 */
-func CreateShowSearchQuery(showSearchQuery *ShowSearchQuery) string {
-	queryStr := "{\"selector\":{\"recordType\": 1"
+func checkClientRole(ctx contractapi.TransactionContextInterface, roles ...string) error {
+	role, found, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return err
+	} else if !found {
+		return errors.New("ACCESS_DENIED")
+	}
+
+	for _, allowedRole := range roles {
+		if role == allowedRole {
+			return nil
+		}
+	}
+	return errors.New("ACCESS_DENIED")
+}
+
+/**
+	Function to check whether invoking client has one of the given roles and belongs to the organisation owning the theatre
+*/
+func checkTheatreOwner(ctx contractapi.TransactionContextInterface, theatre *Theatre, roles ...string) error {
+	if err := checkClientRole(ctx, roles...); err != nil {
+		return err
+	}
+
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return err
+	} else if theatre.OwnerMspId == "" || theatre.OwnerMspId != mspId {
+		return errors.New("ACCESS_DENIED")
+	}
+	return nil
+}
+
+/**
+	Function to check whether invoking client has the given MSP id and identity
+*/
+func checkClientIdentity(ctx contractapi.TransactionContextInterface, ownerMspId, ownerId string) error {
+	mspId, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return err
+	}
+	clientId, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	} else if ownerId == "" || ownerMspId != mspId || ownerId != clientId {
+		return errors.New("ACCESS_DENIED")
+	}
+	return nil
+}
+
+/**
+	Function to check whether invoking client is the client which booked the ticket
+*/
+func checkTicketOwner(ctx contractapi.TransactionContextInterface, ticket *Ticket) error {
+	return checkClientIdentity(ctx, ticket.OwnerMspId, ticket.OwnerId)
+}
+
+/**
+	Function to check whether invoking client can book tickets in a theatre. Customers can book tickets in any
+	theatre, box office can book tickets only in its own theatre
+*/
+func checkBookingClient(ctx contractapi.TransactionContextInterface, theatre *Theatre) error {
+	if err := checkClientRole(ctx, roleCustomer, roleBoxOffice); err != nil {
+		return err
+	} else if checkClientRole(ctx, roleCustomer) != nil {
+		return checkTheatreOwner(ctx, theatre, roleBoxOffice)
+	}
+	return nil
+}
+
+/**
+	Function to get a movie hall, returns nil if movie hall is not registered
+*/
+func getMovieHall(ctx contractapi.TransactionContextInterface, theatreId string, movieHallNo int) (*MovieHall, error) {
+	key, _ := getCompositeKey(ctx, movieHallKeyIndex, theatreId, strconv.Itoa(movieHallNo))
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	movieHall := new(MovieHall)
+	if err = json.Unmarshal(data, &movieHall); err != nil {
+		return nil, err
+	}
+	return movieHall, nil
+}
+
+/**
+	Function to get no. of seats in a movie hall. Theatre's tickets per show is used for
+	movie halls which are not registered
+*/
+func getMovieHallCapacity(ctx contractapi.TransactionContextInterface, theatre *Theatre, movieHallNo int) (int, error) {
+	movieHall, err := getMovieHall(ctx, theatre.TheatreId, movieHallNo)
+	if err != nil {
+		return 0, err
+	} else if movieHall == nil {
+		return theatre.TicketsPerShow, nil
+	}
+	return movieHall.Capacity, nil
+}
+
+/**
+	Function to check whether a movie hall has the screen type required by a show
+*/
+func checkScreenType(ctx contractapi.TransactionContextInterface, show *Show, movieHallNo int) error {
+	if show.RequiredScreenType == "" {
+		return nil
+	}
+
+	movieHall, err := getMovieHall(ctx, show.TheatreId, movieHallNo)
+	if err != nil {
+		return err
+	} else if movieHall == nil || movieHall.ScreenType != show.RequiredScreenType {
+		return errors.New("SCREEN_TYPE_NOT_AVAILABLE")
+	}
+	return nil
+}
+
+/**
+	Function to check whether seat type is one of the known seat types
+*/
+func validateSeatType(seatType string) error {
+	if seatType != seatTypeStandard && seatType != seatTypePremium && seatType != seatTypeRecliner {
+		return errors.New("INVALID_SEAT_TYPE")
+	}
+	return nil
+}
+
+/**
+	Function to validate price per seat by seat type
+*/
+func validatePrices(prices map[string]int64) error {
+	for seatType, price := range prices {
+		if err := validateSeatType(seatType); err != nil {
+			return err
+		} else if price < 0 {
+			return errors.New("INVALID_PRICE")
+		}
+	}
+	return nil
+}
+
+/**
+	Function to validate a price band of a price list
+*/
+func validatePriceBand(priceBand PriceBand) error {
+	if err := validateShowTime(priceBand.StartTime); err != nil {
+		return err
+	} else if err = validateShowTime(priceBand.EndTime); err != nil {
+		return err
+	} else if priceBand.StartTime >= priceBand.EndTime || priceBand.MovieHallNo < 0 || len(priceBand.Prices) == 0 {
+		return errors.New("INVALID_PRICE_BAND")
+	}
+	if err := validateWeekdays(priceBand.Weekdays); err != nil {
+		return err
+	}
+	return validatePrices(priceBand.Prices)
+}
+
+/**
+	Function to validate weekday names e.g. Monday, Tuesday
+*/
+func validateWeekdays(weekdays []string) error {
+	for _, weekday := range weekdays {
+		valid := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if weekday == d.String() {
+				valid = true
+			}
+		}
+		if !valid {
+			return errors.New("INVALID_WEEKDAY")
+		}
+	}
+	return nil
+}
+
+/**
+	Function to get price list of a theatre, returns nil if price list is not set
+*/
+func getPriceList(ctx contractapi.TransactionContextInterface, theatreId string) (*PriceList, error) {
+	key, _ := getCompositeKey(ctx, priceListKeyIndex, theatreId)
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	priceList := new(PriceList)
+	if err = json.Unmarshal(data, &priceList); err != nil {
+		return nil, err
+	}
+	return priceList, nil
+}
+
+/**
+	Function to get price of a seat of a show, show's own prices take precedence over theatre's price list.
+	Seats of a show without prices in a theatre without price list are free, as they were before ticket pricing
+*/
+func getSeatPrice(show *Show, priceList *PriceList, weekday, seatType string) (int64, bool) {
+	if price, ok := show.Prices[seatType]; ok {
+		return price, true
+	} else if priceList == nil {
+		return 0, len(show.Prices) == 0
+	}
+
+	for _, priceBand := range priceList.PriceBands {
+		if priceBand.MovieHallNo != 0 && priceBand.MovieHallNo != show.MovieHallNo {
+			continue
+		} else if show.ShowTime < priceBand.StartTime || show.ShowTime >= priceBand.EndTime {
+			continue
+		}
+		onWeekday := len(priceBand.Weekdays) == 0
+		for _, d := range priceBand.Weekdays {
+			if d == weekday {
+				onWeekday = true
+			}
+		}
+		if price, ok := priceBand.Prices[seatType]; ok && onWeekday {
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+/**
+	Function to compute price of a ticket from no. of seats booked per seat type
+*/
+func getTicketPrice(ctx contractapi.TransactionContextInterface, show *Show, seatTypeCount map[string]int) ([]TicketPrice, int64, error) {
+	showDate, err := parseDate(show.ShowDate)
+	if err != nil {
+		return nil, 0, err
+	}
+	priceList, err := getPriceList(ctx, show.TheatreId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Seat types are sorted so that every peer computes the same breakdown
+	seatTypes := make([]string, 0, len(seatTypeCount))
+	for seatType := range seatTypeCount {
+		seatTypes = append(seatTypes, seatType)
+	}
+	sort.Strings(seatTypes)
+
+	priceBreakdown := []TicketPrice{}
+	var totalPrice int64
+	for _, seatType := range seatTypes {
+		unitPrice, ok := getSeatPrice(show, priceList, showDate.Weekday().String(), seatType)
+		if !ok {
+			return nil, 0, fmt.Errorf("PRICE_NOT_SET: seat type %s", seatType)
+		}
+		ticketPrice := TicketPrice{SeatType: seatType, NoOfSeats: seatTypeCount[seatType], UnitPrice: unitPrice}
+		ticketPrice.Amount = unitPrice * int64(ticketPrice.NoOfSeats)
+		priceBreakdown = append(priceBreakdown, ticketPrice)
+		totalPrice += ticketPrice.Amount
+	}
+	return priceBreakdown, totalPrice, nil
+}
+
+/**
+	Function to get a cafeteria item, returns nil if item is not in theatre's catalogue
+*/
+func getCafeteriaItem(ctx contractapi.TransactionContextInterface, theatreId, sku string) (*CafeteriaItem, error) {
+	key, _ := getCompositeKey(ctx, cafeteriaItemKeyIndex, theatreId, sku)
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	cafeteriaItem := new(CafeteriaItem)
+	if err = json.Unmarshal(data, &cafeteriaItem); err != nil {
+		return nil, err
+	}
+	return cafeteriaItem, nil
+}
+
+/**
+	Function to add quantity to stock of a cafeteria item, negative quantity removes stock
+*/
+func updateCafeteriaStock(ctx contractapi.TransactionContextInterface, theatreId, sku string, quantity int) (*CafeteriaItem, error) {
+	cafeteriaItem, err := getCafeteriaItem(ctx, theatreId, sku)
+	if err != nil {
+		return nil, err
+	} else if cafeteriaItem == nil {
+		return nil, fmt.Errorf("INVALID_SKU: %s", sku)
+	} else if cafeteriaItem.Stock+quantity < 0 {
+		return nil, fmt.Errorf("OUT_OF_STOCK: %s", sku)
+	}
+
+	cafeteriaItem.Stock += quantity
+	key, _ := getCompositeKey(ctx, cafeteriaItemKeyIndex, theatreId, sku)
+	cafeteriaItemAsBytes, _ := json.Marshal(cafeteriaItem)
+	if err = ctx.GetStub().PutState(key, cafeteriaItemAsBytes); err != nil {
+		return nil, err
+	}
+	return cafeteriaItem, nil
+}
+
+/**
+	Function to return items to stock of a theatre's cafeteria. Quantity of every sku is added in one write as
+	stock written in a transaction can not be read back
+*/
+func restockCafeteriaItems(ctx contractapi.TransactionContextInterface, theatreId string, restock map[string]int) error {
+	skus := []string{}
+	for sku := range restock {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	for _, sku := range skus {
+		if restock[sku] == 0 {
+			continue
+		}
+		if _, err := updateCafeteriaStock(ctx, theatreId, sku, restock[sku]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+	Function to get cafeteria orders placed for a ticket
+*/
+func getTicketCafeteriaOrders(ctx contractapi.TransactionContextInterface, ticketId string) ([]*CafeteriaOrder, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(ticketOrderKeyIndex, []string{ticketId})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	cafeteriaOrders := []*CafeteriaOrder{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResult.Key)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ctx.GetStub().GetState(keyParts[len(keyParts)-1])
+		if err != nil {
+			return nil, err
+		} else if data == nil {
+			continue
+		}
+		cafeteriaOrder := new(CafeteriaOrder)
+		if err = json.Unmarshal(data, &cafeteriaOrder); err != nil {
+			return nil, err
+		}
+		cafeteriaOrders = append(cafeteriaOrders, cafeteriaOrder)
+	}
+	return cafeteriaOrders, nil
+}
+
+/**
+	Function to cancel cafeteria orders which are not delivered yet, returns ids of cancelled orders.
+	Items to return to stock are added to restock by sku
+*/
+func cancelCafeteriaOrders(ctx contractapi.TransactionContextInterface, cafeteriaOrders []*CafeteriaOrder, restock map[string]int) ([]string, error) {
+	cancelledOrderIds := []string{}
+	for _, cafeteriaOrder := range cafeteriaOrders {
+		if cafeteriaOrder.Status != orderStatusPlaced && cafeteriaOrder.Status != orderStatusPrepared {
+			continue
+		}
+		for _, orderItem := range cafeteriaOrder.Items {
+			restock[orderItem.Sku] += orderItem.Quantity
+		}
+		cafeteriaOrder.Status = orderStatusCancelled
+		cafeteriaOrderAsBytes, _ := json.Marshal(cafeteriaOrder)
+		if err := ctx.GetStub().PutState(cafeteriaOrder.OrderId, cafeteriaOrderAsBytes); err != nil {
+			return nil, err
+		}
+		cancelledOrderIds = append(cancelledOrderIds, cafeteriaOrder.OrderId)
+	}
+	return cancelledOrderIds, nil
+}
+
+/**
+	Function to move cafeteria orders which are not delivered yet to the show of a rescheduled ticket
+*/
+func moveCafeteriaOrders(ctx contractapi.TransactionContextInterface, cafeteriaOrders []*CafeteriaOrder, ticket *Ticket) error {
+	for _, cafeteriaOrder := range cafeteriaOrders {
+		if cafeteriaOrder.Status != orderStatusPlaced && cafeteriaOrder.Status != orderStatusPrepared {
+			continue
+		}
+		cafeteriaOrder.ShowDate = ticket.ShowDate
+		cafeteriaOrder.ShowTime = ticket.ShowTime
+		cafeteriaOrder.MovieHallNo = ticket.MovieHallNo
+		cafeteriaOrderAsBytes, _ := json.Marshal(cafeteriaOrder)
+		if err := ctx.GetStub().PutState(cafeteriaOrder.OrderId, cafeteriaOrderAsBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+	Function to undo every cafeteria item redemption of a ticket of the given theatre, items to return to stock are added
+	to restock by sku. Soda bottle given before the cafeteria catalogue goes back to the legacy stock until it is migrated
+*/
+func undoRedemptions(ctx contractapi.TransactionContextInterface, theatreId, ticketId string, restock map[string]int) error {
+	if data, err := ctx.GetStub().GetState(legacyReplacementKeyPrefix + ticketId); err != nil {
+		return err
+	} else if data != nil {
+		if err = ctx.GetStub().DelState(legacyReplacementKeyPrefix + ticketId); err != nil {
+			return err
+		}
+		legacyCafeteria := new(LegacyCafeteria)
+		if data, err = ctx.GetStub().GetState(legacyCafeteriaKeyPrefix + theatreId); err != nil {
+			return err
+		} else if data == nil {
+			restock[sodaBottleSku]++
+		} else if err = json.Unmarshal(data, &legacyCafeteria); err != nil {
+			return err
+		} else {
+			legacyCafeteria.SodaBottleQuantity++
+			legacyCafeteriaAsBytes, _ := json.Marshal(legacyCafeteria)
+			if err = ctx.GetStub().PutState(legacyCafeteriaKeyPrefix+theatreId, legacyCafeteriaAsBytes); err != nil {
+				return err
+			}
+		}
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(redemptionKeyIndex, []string{ticketId})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		redemption := new(Redemption)
+		if err = json.Unmarshal(queryResult.Value, &redemption); err != nil {
+			return err
+		}
+		if err = ctx.GetStub().DelState(queryResult.Key); err != nil {
+			return err
+		}
+		restock[redemption.Sku]++
+
+		// Reward given back is available to other tickets of the promotion
+		if redemption.PromotionId == "" {
+			continue
+		}
+		promotionKey, _ := getCompositeKey(ctx, promotionKeyIndex, redemption.TheatreId, redemption.PromotionId)
+		if promotion, err := getPromotion(ctx, promotionKey); err != nil {
+			return err
+		} else if promotion != nil && promotion.Redemptions > 0 {
+			promotion.Redemptions--
+			promotionAsBytes, _ := json.Marshal(promotion)
+			if err = ctx.GetStub().PutState(promotionKey, promotionAsBytes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/**
+	Function to get lucky draw of a show, returns nil if seed is not committed for the show
+*/
+func getLuckyDraw(ctx contractapi.TransactionContextInterface, theatreId, showDate, showTime string, movieHallNo int) (*LuckyDraw, error) {
+	key, _ := getCompositeKey(ctx, luckyDrawKeyIndex, theatreId, showDate, showTime, strconv.Itoa(movieHallNo))
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	luckyDraw := new(LuckyDraw)
+	if err = json.Unmarshal(data, &luckyDraw); err != nil {
+		return nil, err
+	}
+	return luckyDraw, nil
+}
+
+/**
+	Function to get hex encoded SHA-256 hash of a lucky draw seed
+*/
+func getSeedCommitment(seed string) string {
+	hash := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(hash[:])
+}
+
+/**
+	Function to derive lucky no. of a ticket from seed of the lucky draw and transaction which booked the ticket,
+	anyone can recompute it once the seed is revealed
+*/
+func getLuckyNo(seed, bookingTxId string) int {
+	hash := sha256.Sum256([]byte(seed + bookingTxId))
+	return int(binary.BigEndian.Uint32(hash[:4])%maxLuckyNo) + 1
+}
+
+/**
+	Function to draw lucky no. of a ticket if seed of the show's lucky draw is revealed. Tickets booked before the seed
+	is committed get no lucky no., as the seed could have been chosen knowing their booking transactions. Lucky no. is
+	computed whenever the ticket is read, so it need not be stored
+*/
+func drawLuckyNo(ctx contractapi.TransactionContextInterface, ticket *Ticket) error {
+	if ticket.LuckyNo > 0 || ticket.BookingTxId == "" {
+		return nil
+	}
+
+	luckyDraw, err := getLuckyDraw(ctx, ticket.TheatreId, ticket.ShowDate, ticket.ShowTime, ticket.MovieHallNo)
+	if err != nil {
+		return err
+	} else if luckyDraw == nil || luckyDraw.Seed == "" || ticket.BookingNo < luckyDraw.FromBookingNo {
+		return nil
+	}
+	ticket.LuckyNo = getLuckyNo(luckyDraw.Seed, ticket.BookingTxId)
+	return nil
+}
+
+/**
+	Function to get a promotion by its key, returns nil if promotion does not exist
+*/
+func getPromotion(ctx contractapi.TransactionContextInterface, key string) (*Promotion, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	promotion := new(Promotion)
+	if err = json.Unmarshal(data, &promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+/**
+	Function to validate a promotion
+*/
+func validatePromotion(promotion *Promotion) error {
+	if promotion.PromotionId == "" || promotion.TheatreId == "" || promotion.Sku == "" || promotion.MaxRedemptions < 0 {
+		return errors.New("INVALID_PROMOTION")
+	}
+
+	var startDate, endDate time.Time
+	var err error
+	if promotion.StartDate != "" {
+		if startDate, err = parseDate(promotion.StartDate); err != nil {
+			return fmt.Errorf("%s: start date %s", err.Error(), promotion.StartDate)
+		}
+	}
+	if promotion.EndDate != "" {
+		if endDate, err = parseDate(promotion.EndDate); err != nil {
+			return fmt.Errorf("%s: end date %s", err.Error(), promotion.EndDate)
+		} else if promotion.StartDate != "" && endDate.Before(startDate) {
+			return errors.New("INVALID_PROMOTION")
+		}
+	}
+
+	conditions := promotion.Conditions
+	if conditions.MinSeats < 0 || conditions.EveryNthBooking < 0 || conditions.MaxBookingNo < 0 || conditions.LuckyNoMultipleOf < 0 {
+		return errors.New("INVALID_PROMOTION")
+	}
+	return validateWeekdays(conditions.Weekdays)
+}
+
+/**
+	Function to check whether a ticket is eligible for reward of a promotion
+*/
+func isEligibleForPromotion(promotion *Promotion, ticket *Ticket) bool {
+	if !promotion.Active || (promotion.MaxRedemptions > 0 && promotion.Redemptions >= promotion.MaxRedemptions) {
+		return false
+	}
+	// Dates in YYYY-MM-DD format can be compared as strings
+	if (promotion.StartDate != "" && ticket.ShowDate < promotion.StartDate) || (promotion.EndDate != "" && ticket.ShowDate > promotion.EndDate) {
+		return false
+	}
+
+	conditions := promotion.Conditions
+	if conditions.ShowId != "" && conditions.ShowId != ticket.ShowId {
+		return false
+	} else if conditions.MinSeats > 0 && ticket.NoOfSeats < conditions.MinSeats {
+		return false
+	} else if conditions.EveryNthBooking > 0 && (ticket.BookingNo < 1 || ticket.BookingNo%conditions.EveryNthBooking != 0) {
+		return false
+	} else if conditions.MaxBookingNo > 0 && (ticket.BookingNo < 1 || ticket.BookingNo > conditions.MaxBookingNo) {
+		return false
+	} else if conditions.LuckyNoMultipleOf > 0 && (ticket.LuckyNo < 1 || ticket.LuckyNo%conditions.LuckyNoMultipleOf != 0) {
+		return false
+	}
+
+	if len(conditions.Weekdays) > 0 {
+		showDate, err := parseDate(ticket.ShowDate)
+		if err != nil {
+			return false
+		}
+		onWeekday := false
+		for _, weekday := range conditions.Weekdays {
+			if weekday == showDate.Weekday().String() {
+				onWeekday = true
+			}
+		}
+		return onWeekday
+	}
+	return true
+}
+
+/**
+	Function to find a promotion of the theatre under which the ticket can get the cafeteria item,
+	returns nil if ticket is not eligible for any promotion
+*/
+func findPromotion(ctx contractapi.TransactionContextInterface, ticket *Ticket, sku string) (*Promotion, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(promotionKeyIndex, []string{ticket.TheatreId})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	// Promotions are checked in order of their ids so that every peer picks the same promotion
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		promotion := new(Promotion)
+		if err = json.Unmarshal(queryResult.Value, &promotion); err != nil {
+			return nil, err
+		}
+		if promotion.Sku == sku && isEligibleForPromotion(promotion, ticket) {
+			return promotion, nil
+		}
+	}
+	return nil, nil
+}
+
+/**
+	Function to get a ticket, returns nil if ticket does not exist
+*/
+func getTicket(ctx contractapi.TransactionContextInterface, ticketId string) (*Ticket, error) {
+	data, err := ctx.GetStub().GetState(ticketId)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	ticket := new(Ticket)
+	if err = json.Unmarshal(data, &ticket); err != nil {
+		return nil, err
+	} else if ticket.RecordType != 2 {
+		return nil, nil
+	} else if err = completeTicket(ctx, ticket); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+/**
+	Function to fill fields of a ticket which are not stored on it, i.e. status of tickets booked before ticket status
+	was introduced and lucky no. once the show's lucky draw is revealed
+*/
+func completeTicket(ctx contractapi.TransactionContextInterface, ticket *Ticket) error {
+	if ticket.Status == "" {
+		// Tickets booked before ticket status was introduced
+		ticket.Status = ticketStatusBooked
+	}
+	if ticket.BookingTxId == "" {
+		// Lucky no. of tickets booked before the lucky draw was provided by client
+		ticket.LuckyNo = 0
+	}
+	return drawLuckyNo(ctx, ticket)
+}
+
+/**
+	Function to check whether any cafeteria item is redeemed against a ticket
+*/
+func hasRedemptions(ctx contractapi.TransactionContextInterface, ticketId string) (bool, error) {
+	// Soda bottle given before the cafeteria catalogue
+	if data, err := ctx.GetStub().GetState(legacyReplacementKeyPrefix + ticketId); err != nil {
+		return false, err
+	} else if data != nil {
+		return true, nil
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(redemptionKeyIndex, []string{ticketId})
+	if err != nil {
+		return false, err
+	}
+	defer resultsIterator.Close()
+	return resultsIterator.HasNext(), nil
+}
+
+/**
+	Function to check whether a ticket can be transferred as per transfer rules of its theatre
+*/
+func checkTicketTransferRules(ctx contractapi.TransactionContextInterface, theatre *Theatre, ticket *Ticket) error {
+	if ticket.Status != ticketStatusBooked {
+		return errors.New("TICKET_NOT_TRANSFERABLE")
+	}
+
+	if !theatre.TransferRules.AllowAfterShowStart {
+		location, err := getTheatreLocation(theatre)
+		if err != nil {
+			return errors.New("INVALID_TIME_ZONE")
+		}
+		showStartTime, err := getShowStartTime(ticket.ShowDate, ticket.ShowTime, location)
+		if err != nil {
+			return err
+		}
+		if txTime, err := getTxTime(ctx); err != nil {
+			return err
+		} else if !txTime.Before(showStartTime) {
+			return errors.New("SHOW_ALREADY_STARTED")
+		}
+	}
+
+	if !theatre.TransferRules.AllowAfterRedemption {
+		if redeemed, err := hasRedemptions(ctx, ticket.TicketId); err != nil {
+			return err
+		} else if redeemed {
+			return errors.New("TICKET_ALREADY_REDEEMED")
+		}
+	}
+	return nil
+}
+
+/**
+	Function to change owner of a ticket. Customer details of the previous owner are removed from private data collection
+*/
+func changeTicketOwner(ctx contractapi.TransactionContextInterface, ticket *Ticket, ownerMspId, ownerId string) error {
+	if ticket.CustomerHash != "" {
+		if err := ctx.GetStub().DelPrivateData(customerDetailsCollection, ticket.TicketId); err != nil {
+			return err
+		}
+		ticket.CustomerHash = ""
+	}
+
+	ticket.OwnerMspId = ownerMspId
+	ticket.OwnerId = ownerId
+	ticket.PendingTransfer = nil
+	ticketAsBytes, _ := json.Marshal(ticket)
+	return ctx.GetStub().PutState(ticket.TicketId, ticketAsBytes)
+}
+
+/**
+	Function to get event data of a ticket transfer
+*/
+func getTicketTransferEventData(ticket *Ticket, fromMspId, toMspId string) *TicketTransferEventData {
+	ticketTransferEventData := new(TicketTransferEventData)
+	ticketTransferEventData.TicketId = ticket.TicketId
+	ticketTransferEventData.TheatreId = ticket.TheatreId
+	ticketTransferEventData.ShowId = ticket.ShowId
+	ticketTransferEventData.ShowDate = ticket.ShowDate
+	ticketTransferEventData.ShowTime = ticket.ShowTime
+	ticketTransferEventData.MovieHallNo = ticket.MovieHallNo
+	ticketTransferEventData.FromMspId = fromMspId
+	ticketTransferEventData.ToMspId = toMspId
+	return ticketTransferEventData
+}
+
+/**
+	Function to get a seat hold, returns nil if seat hold does not exist
+*/
+func getSeatHold(ctx contractapi.TransactionContextInterface, holdId string) (*SeatHold, error) {
+	data, err := ctx.GetStub().GetState(holdId)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	seatHold := new(SeatHold)
+	if err = json.Unmarshal(data, &seatHold); err != nil {
+		return nil, err
+	} else if seatHold.RecordType != 12 {
+		return nil, nil
+	}
+	return seatHold, nil
+}
+
+/**
+	Function to check whether a seat hold has expired at the given time. Hold with an invalid expiry is treated as expired
+*/
+func isHoldExpired(expiresAt string, now time.Time) bool {
+	expiryTime, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiryTime)
+}
+
+/**
+	Function to check selected seats of a show are available, returns no. of seats and no. of seats per seat type.
+	Seats held by the given seat hold are treated as available
+*/
+func checkSeatsAvailable(ctx contractapi.TransactionContextInterface, theatreId, showId, showDate, showTime string, movieHallNo int, seats []string, noOfSeats int, holdId string) (int, map[string]int, error) {
+	seatMap, err := getSeatMap(ctx, theatreId, movieHallNo)
+	if err != nil {
+		return 0, nil, err
+	} else if seatMap == nil && len(seats) > 0 {
+		return 0, nil, errors.New("SEAT_SELECTION_NOT_SUPPORTED")
+	} else if seatMap != nil {
+		if len(seats) == 0 {
+			return 0, nil, errors.New("SEATS_NOT_SELECTED")
+		}
+		noOfSeats = len(seats)
+	}
+
+	// Check availableSteats should be >= requiredSeats
+	seatAvailability, err := getSeatAvailability(ctx, theatreId, showId, showDate, showTime, movieHallNo, holdId)
+	if err != nil {
+		return 0, nil, err
+	} else if seatAvailability.AvailableSeats < noOfSeats {
+		return 0, nil, errors.New("SEATS_NOT_AVAILABLE")
+	}
+
+	// Check every selected seat exists in the seat map and is still free
+	seatTypeCount := make(map[string]int)
+	if seatMap != nil {
+		freeSeats := make(map[string]string)
+		for _, seat := range seatAvailability.FreeSeats {
+			freeSeats[getSeatLabel(seat)] = seat.SeatType
+		}
+		for _, seatLabel := range seats {
+			seatType, ok := freeSeats[seatLabel]
+			if !ok {
+				return 0, nil, errors.New("SEATS_NOT_AVAILABLE")
+			}
+			seatTypeCount[seatType]++
+			// Same seat should not be selected twice
+			delete(freeSeats, seatLabel)
+		}
+	} else {
+		seatTypeCount[seatTypeStandard] = noOfSeats
+	}
+	return noOfSeats, seatTypeCount, nil
+}
+
+/**
+	Function to get event data of a cafeteria order
+*/
+func getCafeteriaOrderEventData(cafeteriaOrder *CafeteriaOrder) *CafeteriaOrderEventData {
+	cafeteriaOrderEventData := new(CafeteriaOrderEventData)
+	cafeteriaOrderEventData.OrderId = cafeteriaOrder.OrderId
+	cafeteriaOrderEventData.TicketId = cafeteriaOrder.TicketId
+	cafeteriaOrderEventData.TheatreId = cafeteriaOrder.TheatreId
+	cafeteriaOrderEventData.ShowDate = cafeteriaOrder.ShowDate
+	cafeteriaOrderEventData.ShowTime = cafeteriaOrder.ShowTime
+	cafeteriaOrderEventData.MovieHallNo = cafeteriaOrder.MovieHallNo
+	cafeteriaOrderEventData.Seats = cafeteriaOrder.Seats
+	cafeteriaOrderEventData.Status = cafeteriaOrder.Status
+	return cafeteriaOrderEventData
+}
+
+/**
+	Function to get a theatre, returns nil if theatre is not registered
+*/
+func getTheatre(ctx contractapi.TransactionContextInterface, theatreId string) (*Theatre, error) {
+	data, err := ctx.GetStub().GetState(theatreId)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	theatre := new(Theatre)
+	if err = json.Unmarshal(data, &theatre); err != nil {
+		return nil, err
+	} else if theatre.RecordType != 3 {
+		return nil, nil
+	}
+	return theatre, nil
+}
+
+/**
+	Function to create rich query string from a selector. Selector is marshalled to json so that
+	values provided by client are escaped and can not change the structure of the query
+*/
+func createRichQuery(selector map[string]interface{}, index string) string {
+	richQuery := new(RichQuery)
+	richQuery.Selector = selector
+	richQuery.UseIndex = []string{"_design/" + index + "Doc", index}
+	richQueryAsBytes, _ := json.Marshal(richQuery)
+	return string(richQueryAsBytes)
+}
+
+/**
+	Function to validate optional show date and show time of a show search query
+*/
+func validateShowSearchQuery(showSearchQuery *ShowSearchQuery) error {
+	if showSearchQuery.ShowDate != "" {
+		if _, err := parseDate(showSearchQuery.ShowDate); err != nil {
+			return fmt.Errorf("%s: show date %s", err.Error(), showSearchQuery.ShowDate)
+		}
+	}
+	if showSearchQuery.ShowTime != "" {
+		if err := validateShowTime(showSearchQuery.ShowTime); err != nil {
+			return fmt.Errorf("%s: show time %s", err.Error(), showSearchQuery.ShowTime)
+		}
+	}
+	return nil
+}
+
+/**
+	Function to create rich query string, shows whose sales close after salesCloseAfter are matched if it is not empty
+*/
+func CreateShowSearchQuery(showSearchQuery *ShowSearchQuery, salesCloseAfter string) string {
+	selector := map[string]interface{}{"recordType": 1}
+
+	// Sales close time is in UTC and RFC3339 format, so it compares as a string
+	if salesCloseAfter != "" {
+		selector["salesCloseAt"] = map[string]interface{}{"$gt": salesCloseAfter}
+	}
+
+	if showSearchQuery.TheatreId != "" {
+		selector["theatreId"] = showSearchQuery.TheatreId
+	}
+
+	if showSearchQuery.ShowId != "" {
+		selector["showId"] = showSearchQuery.ShowId
+	}
+
+	if showSearchQuery.ShowName != "" {
+		selector["showName"] = showSearchQuery.ShowName
+	}
+
+	if showSearchQuery.ShowTime != "" {
+		selector["showTime"] = showSearchQuery.ShowTime
+	}
+
+	if showSearchQuery.ShowDate != "" {
+		selector["showDate"] = showSearchQuery.ShowDate
+	}
+
+	// Use the index covering most of the fields in selector
+	index := showIndex
+	if showSearchQuery.TheatreId != "" && showSearchQuery.ShowDate != "" {
+		index = showByTheatreDateIndex
+	} else if showSearchQuery.ShowId != "" {
+		index = showByIdIndex
+	} else if showSearchQuery.ShowName != "" {
+		index = showByNameIndex
+	} else if showSearchQuery.TheatreId != "" {
+		index = showByTheatreIndex
+	}
+
+	return createRichQuery(selector, index)
+}
+
+/**
+	Function to create rich query string to get shows of a theatre on or after the given show date
+*/
+func CreateTheatreShowsQuery(theatreId, fromShowDate string) string {
+	selector := map[string]interface{}{
+		"recordType": 1,
+		"theatreId":  theatreId,
+		"showDate":   map[string]interface{}{"$gte": fromShowDate},
+	}
+	return createRichQuery(selector, showByTheatreDateIndex)
+}
+
+/**
+	Function to create rich query string to get tickets of a show
+*/
+func CreateShowTicketsQuery(theatreId, showDate, showTime string, movieHallNo int) string {
+	selector := map[string]interface{}{
+		"recordType":  2,
+		"theatreId":   theatreId,
+		"showDate":    showDate,
+		"showTime":    showTime,
+		"movieHallNo": movieHallNo,
+	}
+	return createRichQuery(selector, ticketByShowIndex)
+}
+
+/**
+	Function to create rich query string to get orders of a show which are not delivered yet
+*/
+func CreateShowOpenOrdersQuery(theatreId, showDate, showTime string, movieHallNo int) string {
+	selector := map[string]interface{}{
+		"recordType":  9,
+		"theatreId":   theatreId,
+		"showDate":    showDate,
+		"showTime":    showTime,
+		"movieHallNo": movieHallNo,
+		"status":      map[string]interface{}{"$in": []string{orderStatusPlaced, orderStatusPrepared}},
+	}
+	return createRichQuery(selector, orderByShowIndex)
+}
+
+/**
+	Function to create rich query string to get tickets owned by a client. Filter UPCOMING gets booked tickets of shows
+	starting after txTime, PAST gets tickets of shows started by txTime and empty filter gets all tickets
+*/
+func CreateOwnerTicketsQuery(ownerMspId, ownerId, filter, txTime string) string {
+	selector := map[string]interface{}{
+		"recordType": 2,
+		"ownerMspId": ownerMspId,
+		"ownerId":    ownerId,
+	}
+
+	// Status and show start time are always in the selector so that the index by show start can be used.
+	// Show start time is in UTC and RFC3339 format, so it compares as a string. Tickets without it are left out
+	if filter == ticketFilterUpcoming {
+		selector["status"] = ticketStatusBooked
+		selector["showStartsAt"] = map[string]interface{}{"$gt": txTime}
+		return createRichQuery(selector, ticketByOwnerStartIndex)
+	} else if filter == ticketFilterPast {
+		selector["status"] = map[string]interface{}{"$gt": nil}
+		selector["showStartsAt"] = map[string]interface{}{"$gt": "", "$lte": txTime}
+		return createRichQuery(selector, ticketByOwnerStartIndex)
+	}
+
+	// Show date is always in the selector so that the index can be used
+	selector["showDate"] = map[string]interface{}{"$gt": nil}
+	return createRichQuery(selector, ticketByOwnerIndex)
+}
+
+/**
+	Function to get all tickets of a show
+*/
+func getShowTickets(ctx contractapi.TransactionContextInterface, theatreId, showDate, showTime string, movieHallNo int) ([]*Ticket, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(CreateShowTicketsQuery(theatreId, showDate, showTime, movieHallNo))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var tickets []*Ticket
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		ticket := new(Ticket)
+		if err = json.Unmarshal(queryResult.Value, &ticket); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, nil
+}
+
+/**
+	Function to get a show by its key, returns nil if show does not exist
+*/
+func getShow(ctx contractapi.TransactionContextInterface, key string) (*Show, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	show := new(Show)
+	if err = json.Unmarshal(data, &show); err != nil {
+		return nil, err
+	}
+	return show, nil
+}
+
+/**
+	Function to delete sold seats, seat holds and seat counter of a show. Seat counter is read before it is deleted
+	so that a ticket booked for the show in a concurrent transaction invalidates this transaction
+*/
+func releaseShowSeats(ctx contractapi.TransactionContextInterface, theatreId, showDate, showTime string, movieHallNo int) error {
+	showSeatCount, err := getShowSeatCount(ctx, theatreId, showDate, showTime, movieHallNo)
+	if err != nil {
+		return err
+	}
+	for _, hold := range showSeatCount.Holds {
+		if err = deleteSeatHold(ctx, hold.HoldId); err != nil {
+			return err
+		}
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(soldSeatKeyIndex, []string{theatreId, showDate, showTime, strconv.Itoa(movieHallNo)})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
 
-	if showSearchQuery.TheatreId != "" {
-		queryStr = queryStr + ",\"theatreId\":\"" + showSearchQuery.TheatreId + "\""
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if err = ctx.GetStub().DelState(queryResult.Key); err != nil {
+			return err
+		}
 	}
 
-	if showSearchQuery.ShowId != "" {
-		queryStr = queryStr + ",\"showId\":\"" + showSearchQuery.ShowId + "\""
+	key, _ := getCompositeKey(ctx, showSeatCountKeyIndex, theatreId, showDate, showTime, strconv.Itoa(movieHallNo))
+	return ctx.GetStub().DelState(key)
+}
+
+/**
+	Function to parse a date in YYYY-MM-DD format
+*/
+func parseDate(date string) (time.Time, error) {
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, errors.New("INVALID_DATE")
 	}
+	return parsedDate, nil
+}
 
-	if showSearchQuery.ShowName != "" {
-		queryStr = queryStr + ",\"showName\":\"" + showSearchQuery.ShowName + "\""
+/**
+	Function to validate a show time in HH:MM 24 hour format
+*/
+func validateShowTime(showTime string) error {
+	if len(showTime) != len("15:04") {
+		return errors.New("INVALID_SHOW_TIME")
+	} else if _, err := time.Parse("15:04", showTime); err != nil {
+		return errors.New("INVALID_SHOW_TIME")
+	}
+	return nil
+}
+
+/**
+	Function to validate date and time of a show
+*/
+func validateShowDateTime(showDate, showTime string) error {
+	if _, err := parseDate(showDate); err != nil {
+		return err
 	}
+	return validateShowTime(showTime)
+}
 
-	if showSearchQuery.ShowTime != "" {
-		queryStr = queryStr + ",\"showTime\":\"" + showSearchQuery.ShowTime + "\""
+/**
+	Function to get time zone of a theatre, theatres registered without a time zone are in UTC
+*/
+func getTheatreLocation(theatre *Theatre) (*time.Location, error) {
+	if theatre.TimeZone == "" {
+		return time.UTC, nil
 	}
+	location, err := time.LoadLocation(theatre.TimeZone)
+	if err != nil {
+		return nil, errors.New("INVALID_TIME_ZONE")
+	}
+	return location, nil
+}
 
-	if showSearchQuery.ShowDate != "" {
-		queryStr = queryStr + ",\"showDate\":\"" + showSearchQuery.ShowDate + "\""
+/**
+	Function to get start time of a show in the theatre's time zone
+*/
+func getShowStartTime(showDate, showTime string, location *time.Location) (time.Time, error) {
+	if err := validateShowDateTime(showDate, showTime); err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation("2006-01-02 15:04", showDate+" "+showTime, location)
+}
+
+/**
+	Function to get time when booking opens and closes for a show. Open time is zero if booking
+	can be done any time before the show
+*/
+func getSalesWindow(theatre *Theatre, showDate, showTime string) (time.Time, time.Time, error) {
+	location, err := getTheatreLocation(theatre)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	showStartTime, err := getShowStartTime(showDate, showTime, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	var salesOpenTime time.Time
+	if theatre.SalesOpenDays > 0 {
+		salesOpenTime = showStartTime.AddDate(0, 0, -theatre.SalesOpenDays)
+	}
+	salesCloseTime := showStartTime.Add(time.Duration(theatre.SalesCloseMinutes) * time.Minute)
+	return salesOpenTime, salesCloseTime, nil
+}
+
+/**
+	Function to check whether tickets of a show can be sold at the time of transaction
+*/
+func checkSalesWindow(ctx contractapi.TransactionContextInterface, theatre *Theatre, showDate, showTime string) error {
+	salesOpenTime, salesCloseTime, err := getSalesWindow(theatre, showDate, showTime)
+	if err != nil {
+		return err
+	}
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txTime.Before(salesOpenTime) {
+		return errors.New("SALES_NOT_OPEN")
+	} else if !txTime.Before(salesCloseTime) {
+		return errors.New("SALES_CLOSED")
+	}
+	return nil
+}
+
+/**
+	Function to set time when sales of a show close, stored so that shows on sale can be queried
+*/
+func setShowSalesCloseTime(theatre *Theatre, show *Show) error {
+	_, salesCloseTime, err := getSalesWindow(theatre, show.ShowDate, show.ShowTime)
+	if err != nil {
+		return err
+	}
+	show.SalesCloseAt = salesCloseTime.UTC().Format(time.RFC3339)
+	return nil
+}
+
+/**
+	Function to check whether invoking client is the ticket owner or has one of the given roles in the theatre's organisation
+*/
+func checkTicketOwnerOrTheatre(ctx contractapi.TransactionContextInterface, ticket *Ticket, roles ...string) error {
+	if checkTicketOwner(ctx, ticket) == nil {
+		return nil
+	}
+
+	theatre, err := getTheatre(ctx, ticket.TheatreId)
+	if err != nil {
+		return err
+	} else if theatre == nil {
+		return errors.New("ACCESS_DENIED")
+	}
+	return checkTheatreOwner(ctx, theatre, roles...)
+}
+
+/**
+	Function to get the time from start of a show till its movie hall is free again
+*/
+func getShowInterval(show *Show, location *time.Location) (time.Time, time.Time, error) {
+	showStartTime, err := getShowStartTime(show.ShowDate, show.ShowTime, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	showEndTime := showStartTime.Add(time.Duration(show.RuntimeMinutes+show.CleaningBufferMinutes) * time.Minute)
+	return showStartTime, showEndTime, nil
+}
+
+/**
+	Function to check whether a show overlaps another show in the same movie hall. Shows of previous
+	and next day are checked too as a show can run past midnight. Show stored at ignoreKey is skipped
+*/
+func checkShowOverlap(ctx contractapi.TransactionContextInterface, show *Show, location *time.Location, ignoreKey string) error {
+	showStartTime, showEndTime, err := getShowInterval(show, location)
+	if err != nil {
+		return err
+	}
+
+	for _, days := range []int{-1, 0, 1} {
+		showDate := showStartTime.AddDate(0, 0, days).Format("2006-01-02")
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(showKeyIndex, []string{show.TheatreId, showDate})
+		if err != nil {
+			return err
+		}
+
+		for resultsIterator.HasNext() {
+			queryResult, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return err
+			}
+			existingShow := new(Show)
+			if err = json.Unmarshal(queryResult.Value, &existingShow); err != nil {
+				resultsIterator.Close()
+				return err
+			}
+			if queryResult.Key == ignoreKey || existingShow.MovieHallNo != show.MovieHallNo || existingShow.Status == showStatusCancelled {
+				continue
+			}
+
+			existingShowStartTime, existingShowEndTime, err := getShowInterval(existingShow, location)
+			if err != nil {
+				// Show registered before show time was validated, only same start time is treated as overlap
+				existingShowStartTime, existingShowEndTime = showStartTime, showStartTime
+				if existingShow.ShowDate != show.ShowDate || existingShow.ShowTime != show.ShowTime {
+					continue
+				}
+			}
+			if existingShowStartTime.Equal(showStartTime) || (existingShowStartTime.Before(showEndTime) && showStartTime.Before(existingShowEndTime)) {
+				resultsIterator.Close()
+				return fmt.Errorf("SHOW_OVERLAP: show %s on date: %s and time: %s in movie hall no %d", existingShow.ShowId, existingShow.ShowDate, existingShow.ShowTime, existingShow.MovieHallNo)
+			}
+		}
+		resultsIterator.Close()
+	}
+	return nil
+}
+
+/**
+	Function to get transaction timestamp as time
+*/
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC(), nil
+}
+
+/**
+	Function to get every version of a key, oldest version first
+*/
+func getKeyHistory(ctx contractapi.TransactionContextInterface, key string) ([]*queryresult.KeyModification, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var history []*queryresult.KeyModification
+	for resultsIterator.HasNext() {
+		keyModification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, keyModification)
+	}
+
+	// History is returned newest first by the peer
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+/**
+	Function to get timestamp of a version of a key in RFC3339 format
+*/
+func getHistoryTimestamp(keyModification *queryresult.KeyModification) string {
+	if keyModification.Timestamp == nil {
+		return ""
+	}
+	return time.Unix(keyModification.Timestamp.Seconds, int64(keyModification.Timestamp.Nanos)).UTC().Format(time.RFC3339)
+}
+
+/**
+	Function to set chaincode event of the transaction
+*/
+func setEvent(ctx contractapi.TransactionContextInterface, eventType string, data interface{}) error {
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	event := new(Event)
+	event.Version = eventSchemaVersion
+	if eventType == cafeteriaInventoryAddedEvent {
+		event.Version = cafeteriaInventoryAddedVersion
+	}
+	event.EventType = eventType
+	event.TxId = ctx.GetStub().GetTxID()
+	event.Timestamp = txTime.Format(time.RFC3339)
+	event.Data = data
+	eventAsBytes, _ := json.Marshal(event)
+	return ctx.GetStub().SetEvent(eventType, eventAsBytes)
+}
+
+/**
+	Function to get event data of a ticket
+*/
+func getTicketEventData(ticket *Ticket) *TicketEventData {
+	ticketEventData := new(TicketEventData)
+	ticketEventData.TicketId = ticket.TicketId
+	ticketEventData.TheatreId = ticket.TheatreId
+	ticketEventData.ShowId = ticket.ShowId
+	ticketEventData.ShowDate = ticket.ShowDate
+	ticketEventData.ShowTime = ticket.ShowTime
+	ticketEventData.MovieHallNo = ticket.MovieHallNo
+	ticketEventData.NoOfSeats = ticket.NoOfSeats
+	ticketEventData.Seats = ticket.Seats
+	ticketEventData.TotalPrice = ticket.TotalPrice
+	return ticketEventData
+}
+
+/**
+	Function to get event data of a show
+*/
+func getShowEventData(show *Show) *ShowEventData {
+	showEventData := new(ShowEventData)
+	showEventData.TheatreId = show.TheatreId
+	showEventData.ShowId = show.ShowId
+	showEventData.ShowName = show.ShowName
+	showEventData.ShowDate = show.ShowDate
+	showEventData.ShowTime = show.ShowTime
+	showEventData.MovieHallNo = show.MovieHallNo
+	showEventData.MovedTicketIds = []string{}
+	showEventData.RefundEligibleTicketIds = []string{}
+	return showEventData
+}
+
+/**
+	Function to check whether seats of a ticket can be booked in a movie hall with the given seat map
+*/
+func ticketFitsSeatMap(ticket *Ticket, seatMap *SeatMap) bool {
+	if seatMap == nil {
+		// Movie hall without seat map can only take tickets without seats
+		return len(ticket.Seats) == 0
+	} else if len(ticket.Seats) == 0 {
+		return false
 	}
 
-	return queryStr + "}}"
+	seatLabels := make(map[string]bool)
+	for _, seat := range seatMap.Seats {
+		seatLabels[getSeatLabel(seat)] = true
+	}
+	for _, seatLabel := range ticket.Seats {
+		if !seatLabels[seatLabel] {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+	Function to validate row label of a seat, row label should have only letters
+*/
+func validateSeatRow(row string) error {
+	if row == "" {
+		return errors.New("INVALID_SEAT_ROW")
+	}
+	for _, r := range row {
+		if !unicode.IsLetter(r) {
+			return errors.New("INVALID_SEAT_ROW")
+		}
+	}
+	return nil
+}
+
+/**
+	Function to get label of a seat e.g. A12
+*/
+func getSeatLabel(seat Seat) string {
+	return seat.Row + strconv.Itoa(seat.SeatNo)
+}
+
+/**
+	Function to get seat map of a movie hall, returns nil if seat map is not registered
+*/
+func getSeatMap(ctx contractapi.TransactionContextInterface, theatreId string, movieHallNo int) (*SeatMap, error) {
+	key, _ := getCompositeKey(ctx, seatMapKeyIndex, theatreId, strconv.Itoa(movieHallNo))
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	} else if data == nil {
+		return nil, nil
+	}
+
+	seatMap := new(SeatMap)
+	if err = json.Unmarshal(data, &seatMap); err != nil {
+		return nil, err
+	}
+	return seatMap, nil
+}
+
+/**
+	Function to get seat counter of a show. Counter of a show booked before seat counters were introduced is
+	computed from its tickets, a zero counter is returned if no seat is sold yet
+*/
+func getShowSeatCount(ctx contractapi.TransactionContextInterface, theatreId, showDate, showTime string, movieHallNo int) (*ShowSeatCount, error) {
+	key, _ := getCompositeKey(ctx, showSeatCountKeyIndex, theatreId, showDate, showTime, strconv.Itoa(movieHallNo))
+	showSeatCount := new(ShowSeatCount)
+	if data, err := ctx.GetStub().GetState(key); err != nil {
+		return nil, err
+	} else if data != nil {
+		if err = json.Unmarshal(data, &showSeatCount); err != nil {
+			return nil, err
+		}
+		return showSeatCount, nil
+	}
+
+	showSeatCount.TheatreId = theatreId
+	showSeatCount.ShowDate = showDate
+	showSeatCount.ShowTime = showTime
+	showSeatCount.MovieHallNo = movieHallNo
+	showSeatCount.RecordType = 5
+
+	tickets, err := getShowTickets(ctx, theatreId, showDate, showTime, movieHallNo)
+	if err != nil {
+		return nil, err
+	}
+	for _, ticket := range tickets {
+		if ticket.Status == ticketStatusBooked || ticket.Status == "" {
+			showSeatCount.SoldSeats += ticket.NoOfSeats
+		}
+		showSeatCount.Bookings++
+	}
+	return showSeatCount, nil
+}
+
+/**
+	Function to add no of seats and bookings to seat counter of a show, use negative no of seats to release seats
+*/
+func updateShowSeatCount(ctx contractapi.TransactionContextInterface, theatreId, showDate, showTime string, movieHallNo int, noOfSeats, noOfBookings int) (*ShowSeatCount, error) {
+	showSeatCount, err := getShowSeatCount(ctx, theatreId, showDate, showTime, movieHallNo)
+	if err != nil {
+		return nil, err
+	}
+
+	showSeatCount.SoldSeats += noOfSeats
+	if showSeatCount.SoldSeats < 0 {
+		return nil, fmt.Errorf("INVALID_SEAT_COUNT: %d seats sold", showSeatCount.SoldSeats)
+	}
+	showSeatCount.Bookings += noOfBookings
+	if err = putShowSeatCount(ctx, showSeatCount); err != nil {
+		return nil, err
+	}
+	return showSeatCount, nil
+}
+
+/**
+	Function to store seat counter of a show. Seat holds expired at the time of transaction are removed from the counter
+	and deleted, so that abandoned seat holds do not pile up in the counter
+*/
+func putShowSeatCount(ctx contractapi.TransactionContextInterface, showSeatCount *ShowSeatCount) error {
+	if _, err := pruneExpiredSeatHolds(ctx, showSeatCount); err != nil {
+		return err
+	}
+	showSeatCountAsBytes, _ := json.Marshal(showSeatCount)
+	key, _ := getCompositeKey(ctx, showSeatCountKeyIndex, showSeatCount.TheatreId, showSeatCount.ShowDate, showSeatCount.ShowTime, strconv.Itoa(showSeatCount.MovieHallNo))
+	return ctx.GetStub().PutState(key, showSeatCountAsBytes)
+}
+
+/**
+	Function to remove a seat hold from seat counter of a show, returns no. of seats held by the seat hold
+*/
+func removeShowSeatHold(showSeatCount *ShowSeatCount, holdId string) int {
+	noOfSeats := 0
+	holds := []SeatHoldCount{}
+	for _, hold := range showSeatCount.Holds {
+		if hold.HoldId == holdId {
+			noOfSeats += hold.NoOfSeats
+			continue
+		}
+		holds = append(holds, hold)
+	}
+	showSeatCount.Holds = holds
+	return noOfSeats
+}
+
+/**
+	Function to remove seat holds expired at the time of transaction from seat counter of a show and delete them,
+	returns no. of seat holds removed
+*/
+func pruneExpiredSeatHolds(ctx contractapi.TransactionContextInterface, showSeatCount *ShowSeatCount) (int, error) {
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	noOfHolds := 0
+	holds := []SeatHoldCount{}
+	for _, hold := range showSeatCount.Holds {
+		if !isHoldExpired(hold.ExpiresAt, txTime) {
+			holds = append(holds, hold)
+			continue
+		}
+		if err = deleteSeatHold(ctx, hold.HoldId); err != nil {
+			return 0, err
+		}
+		noOfHolds++
+	}
+	showSeatCount.Holds = holds
+	return noOfHolds, nil
+}
+
+/**
+	Function to delete a seat hold and its entry among seat holds of the holding client
+*/
+func deleteSeatHold(ctx contractapi.TransactionContextInterface, holdId string) error {
+	seatHold, err := getSeatHold(ctx, holdId)
+	if err != nil {
+		return err
+	} else if seatHold == nil {
+		return nil
+	}
+
+	clientHoldKey, _ := getCompositeKey(ctx, clientHoldKeyIndex, seatHold.OwnerMspId, seatHold.OwnerId, holdId)
+	if err = ctx.GetStub().DelState(clientHoldKey); err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(holdId)
+}
+
+/**
+	Function to count seat holds of a client which are not confirmed and have not expired at the given time
+*/
+func countActiveSeatHolds(ctx contractapi.TransactionContextInterface, ownerMspId, ownerId string, now time.Time) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(clientHoldKeyIndex, []string{ownerMspId, ownerId})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	noOfHolds := 0
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResult.Key)
+		if err != nil {
+			return 0, err
+		}
+		seatHold, err := getSeatHold(ctx, keyParts[len(keyParts)-1])
+		if err != nil {
+			return 0, err
+		} else if seatHold != nil && !isHoldExpired(seatHold.ExpiresAt, now) {
+			noOfHolds++
+		}
+	}
+	return noOfHolds, nil
 }
 
 /**
 	Function to get no of available seats
 */
 func GetSeatAvailability(ctx contractapi.TransactionContextInterface, theatreId, showId, showDate, showTime string, movieHallNo int) (int, error) {
+	seatAvailability, err := getSeatAvailability(ctx, theatreId, showId, showDate, showTime, movieHallNo, "")
+	if err != nil {
+		return 0, err
+	}
+	return seatAvailability.AvailableSeats, nil
+}
 
-	var totalTicketsAvailable int
+/**
+	Function to get available seats. Seats of expired seat holds and of the given seat hold are available
+*/
+func getSeatAvailability(ctx contractapi.TransactionContextInterface, theatreId, showId, showDate, showTime string, movieHallNo int, holdId string) (*SeatAvailability, error) {
 
 	// Get Show
 	key, _ := getCompositeKey(ctx, showKeyIndex, theatreId, showDate, showTime, strconv.Itoa(movieHallNo))
 	if data, err := ctx.GetStub().GetState(key); err != nil {
-		return 0, err
+		return nil, err
 	} else if data == nil {
-		return 0, errors.New("INVALID_SHOW_INFO")
+		return nil, errors.New("INVALID_SHOW_INFO")
+	} else {
+		show := new(Show)
+		_ = json.Unmarshal([]byte(data), &show)
+		if show.ShowId != showId {
+			return nil, errors.New("INVALID_SHOW_INFO")
+		} else if show.Status == showStatusCancelled {
+			return nil, errors.New("SHOW_CANCELLED")
+		}
 	}
 
-	// Get Theatre
-	if data, err := ctx.GetStub().GetState(theatreId); err != nil {
-		return 0, err
-	} else if data == nil {
-		return 0, errors.New("INVALID_THEATRE_ID")
-	} else {
-		theatre := new(Theatre)
-		_ = json.Unmarshal([]byte(data), &theatre)
-		totalTicketsAvailable = theatre.TicketsPerShow
+	// Get no. of sold seats from the show's seat counter. Reading the counter puts it in the
+	// transaction's read set, so concurrent bookings for the same show fail MVCC validation
+	showSeatCount, err := getShowSeatCount(ctx, theatreId, showDate, showTime, movieHallNo)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get no. of sold tickets
-	queryString := "{\"selector\":{\"theatreId\":\"" + theatreId + "\",\"showId\":\"" + showId + "\",\"showDate\":\"" + showDate + "\",\"showTime\":\"" + showTime + "\",\"movieHallNo\":" + strconv.Itoa(movieHallNo) + ",\"recordType\":2}}"
-	// get all tickets and count no. of sold tickets
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	// Seat holds expire against transaction timestamp
+	txTime, err := getTxTime(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	soldTickets := 0
-	var queryResult *queryresult.KV
-	for resultsIterator.HasNext() {
-		queryResult, err = resultsIterator.Next()
+	seatAvailability := new(SeatAvailability)
+	seatAvailability.FreeSeats = []Seat{}
+
+	// Movie hall with a seat map, free seats are the seats which are not sold or held
+	seatMap, err := getSeatMap(ctx, theatreId, movieHallNo)
+	if err != nil {
+		return nil, err
+	} else if seatMap != nil {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(soldSeatKeyIndex, []string{theatreId, showDate, showTime, strconv.Itoa(movieHallNo)})
 		if err != nil {
-			return 0, err
+			return nil, err
+		}
+		defer resultsIterator.Close()
+
+		soldSeats := make(map[string]bool)
+		for resultsIterator.HasNext() {
+			queryResult, err := resultsIterator.Next()
+			if err != nil {
+				return nil, err
+			}
+			_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResult.Key)
+			if err != nil {
+				return nil, err
+			}
+			soldSeat := new(SoldSeat)
+			if err = json.Unmarshal(queryResult.Value, &soldSeat); err == nil && soldSeat.TicketId == "" && soldSeat.HoldId != "" {
+				if soldSeat.HoldId == holdId || isHoldExpired(soldSeat.ExpiresAt, txTime) {
+					continue
+				}
+			}
+			soldSeats[keyParts[len(keyParts)-1]] = true
+		}
+
+		for _, seat := range seatMap.Seats {
+			if !soldSeats[getSeatLabel(seat)] {
+				seatAvailability.FreeSeats = append(seatAvailability.FreeSeats, seat)
+			}
+		}
+		seatAvailability.AvailableSeats = len(seatAvailability.FreeSeats)
+		return seatAvailability, nil
+	}
+
+	// Get Theatre
+	theatre, err := getTheatre(ctx, theatreId)
+	if err != nil {
+		return nil, err
+	} else if theatre == nil {
+		return nil, errors.New("INVALID_THEATRE_ID")
+	}
+
+	// Get capacity of the movie hall
+	totalTicketsAvailable, err := getMovieHallCapacity(ctx, theatre, movieHallNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seats held by other seat holds which have not expired are not available
+	heldSeats := 0
+	for _, hold := range showSeatCount.Holds {
+		if hold.HoldId != holdId && !isHoldExpired(hold.ExpiresAt, txTime) {
+			heldSeats += hold.NoOfSeats
 		}
-		var ticket Ticket
-		_ = json.Unmarshal(queryResult.Value, &ticket)
-		soldTickets += ticket.NoOfSeats
 	}
 
-	return totalTicketsAvailable - soldTickets, nil
+	seatAvailability.AvailableSeats = totalTicketsAvailable - showSeatCount.SoldSeats - heldSeats
+	return seatAvailability, nil
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const indexDir = "META-INF/statedb/couchdb/indexes"
+
+type couchDBIndex struct {
+	Index struct {
+		Fields []string `json:"fields"`
+	} `json:"index"`
+	Ddoc string `json:"ddoc"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+/**
+	Function to load all index definitions shipped with the chaincode, keyed by index name
+*/
+func loadIndexes(t *testing.T) map[string]couchDBIndex {
+	files, err := filepath.Glob(filepath.Join(indexDir, "*.json"))
+	if err != nil {
+		t.Fatalf("Failed to list index definitions: %s", err.Error())
+	} else if len(files) == 0 {
+		t.Fatalf("No index definitions found in %s", indexDir)
+	}
+
+	indexes := make(map[string]couchDBIndex)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read index definition %s: %s", file, err.Error())
+		}
+		var index couchDBIndex
+		if err = json.Unmarshal(data, &index); err != nil {
+			t.Fatalf("Invalid index definition %s: %s", file, err.Error())
+		} else if index.Name == "" || index.Ddoc == "" || index.Type != "json" || len(index.Index.Fields) == 0 {
+			t.Fatalf("Incomplete index definition %s", file)
+		}
+		indexes[index.Name] = index
+	}
+	return indexes
+}
+
+/**
+	Function to get every rich query string the chaincode can build
+*/
+func allRichQueries() []string {
+	var queries []string
+
+	// Show search with every combination of search fields
+	for mask := 0; mask < 32; mask++ {
+		showSearchQuery := new(ShowSearchQuery)
+		if mask&1 != 0 {
+			showSearchQuery.TheatreId = "theatre1"
+		}
+		if mask&2 != 0 {
+			showSearchQuery.ShowId = "show1"
+		}
+		if mask&4 != 0 {
+			showSearchQuery.ShowName = "show \"name\""
+		}
+		if mask&8 != 0 {
+			showSearchQuery.ShowTime = "18:00"
+		}
+		if mask&16 != 0 {
+			showSearchQuery.ShowDate = "2020-01-01"
+		}
+		queries = append(queries, CreateShowSearchQuery(showSearchQuery, ""))
+		queries = append(queries, CreateShowSearchQuery(showSearchQuery, "2020-01-01T12:00:00Z"))
+	}
+
+	// Shows of a theatre from a show date
+	queries = append(queries, CreateTheatreShowsQuery("theatre1", "2020-01-01"))
+
+	// Tickets of a show
+	queries = append(queries, CreateShowTicketsQuery("theatre1", "2020-01-01", "18:00", 1))
+
+	// Open cafeteria orders of a show
+	queries = append(queries, CreateShowOpenOrdersQuery("theatre1", "2020-01-01", "18:00", 1))
+
+	// Tickets of a client, all, upcoming and past
+	queries = append(queries, CreateOwnerTicketsQuery("Org1MSP", "client1", "", "2020-01-01T12:00:00Z"))
+	queries = append(queries, CreateOwnerTicketsQuery("Org1MSP", "client1", ticketFilterUpcoming, "2020-01-01T12:00:00Z"))
+	queries = append(queries, CreateOwnerTicketsQuery("Org1MSP", "client1", ticketFilterPast, "2020-01-01T12:00:00Z"))
+
+	return queries
+}
+
+func TestRichQueriesUseShippedIndexes(t *testing.T) {
+	indexes := loadIndexes(t)
+
+	for _, queryString := range allRichQueries() {
+		var richQuery RichQuery
+		if err := json.Unmarshal([]byte(queryString), &richQuery); err != nil {
+			t.Fatalf("Rich query is not valid json: %s, Error: %s", queryString, err.Error())
+		}
+
+		if len(richQuery.UseIndex) != 2 {
+			t.Errorf("Rich query does not name an index: %s", queryString)
+			continue
+		}
+		index, ok := indexes[richQuery.UseIndex[1]]
+		if !ok {
+			t.Errorf("Rich query uses index %s which is not shipped: %s", richQuery.UseIndex[1], queryString)
+			continue
+		} else if richQuery.UseIndex[0] != "_design/"+index.Ddoc {
+			t.Errorf("Rich query uses design document %s, index %s is in %s", richQuery.UseIndex[0], index.Name, index.Ddoc)
+		}
+
+		// Index can only be used if selector has every field of the index
+		for _, field := range index.Index.Fields {
+			if _, ok := richQuery.Selector[field]; !ok {
+				t.Errorf("Rich query selector does not have field %s of index %s: %s", field, index.Name, queryString)
+			}
+		}
+	}
+}
+
+func TestShowSearchQueryEscapesInput(t *testing.T) {
+	showSearchQuery := new(ShowSearchQuery)
+	showSearchQuery.ShowName = "x\",\"recordType\":2,\"showName\":\"x"
+
+	var richQuery RichQuery
+	if err := json.Unmarshal([]byte(CreateShowSearchQuery(showSearchQuery, "")), &richQuery); err != nil {
+		t.Fatalf("Rich query is not valid json: %s", err.Error())
+	}
+	if richQuery.Selector["recordType"] != float64(1) {
+		t.Errorf("Record type filter changed by show name: %v", richQuery.Selector["recordType"])
+	}
+	if richQuery.Selector["showName"] != showSearchQuery.ShowName {
+		t.Errorf("Show name not matched as a plain value: %v", richQuery.Selector["showName"])
+	}
+}
+
+func TestLuckyNoCanBeRecomputedFromRevealedSeed(t *testing.T) {
+	seed := "seed1"
+	if commitment := getSeedCommitment(seed); commitment != "df9ecf4c79e5ad77701cfc88c196632b353149d85810a381f469f8fc05dc1b92" {
+		t.Errorf("Seed commitment is not hex encoded SHA-256 hash of seed: %s", commitment)
+	}
+
+	for _, txId := range []string{"tx1", "tx2", "tx3", "tx4"} {
+		luckyNo := getLuckyNo(seed, txId)
+		if luckyNo < 1 || luckyNo > maxLuckyNo {
+			t.Errorf("Lucky no. %d for transaction %s is out of range", luckyNo, txId)
+		} else if getLuckyNo(seed, txId) != luckyNo {
+			t.Errorf("Lucky no. for transaction %s is not deterministic", txId)
+		}
+	}
+}
+
+func TestSeatHoldExpiresAtExpiryTime(t *testing.T) {
+	expiresAt := "2020-01-01T18:10:00Z"
+	expiryTime, _ := time.Parse(time.RFC3339, expiresAt)
+
+	if isHoldExpired(expiresAt, expiryTime.Add(-time.Second)) {
+		t.Errorf("Seat hold expired before %s", expiresAt)
+	}
+	if !isHoldExpired(expiresAt, expiryTime) {
+		t.Errorf("Seat hold not expired at %s", expiresAt)
+	}
+	if !isHoldExpired("10 minutes", expiryTime) {
+		t.Errorf("Seat hold with invalid expiry not treated as expired")
+	}
+}